@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,34 +12,63 @@ import (
 	"time"
 
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/agent"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/auth"
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/handlers"
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"github.com/subosito/gotenv"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
 	// Load configuration
 	config, err := loadConfig()
 	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+		fatal("failed to load configuration", err)
+	}
+
+	// Structured logs; the standard log package is routed through the same handler
+	slog.SetDefault(telemetry.NewLogger(os.Stdout, &config.Logging))
+	logProvider(config)
+
+	// Setup tracing before any instrumented client is created
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), &config.Tracing)
+	if err != nil {
+		fatal("failed to setup tracing", err)
 	}
 
 	// Create HTTP agent
-	httpAgent, err := agent.NewHTTPAgent(&config.HTTP, &config.LLM)
+	httpAgent, err := agent.NewHTTPAgent(config)
 	if err != nil {
-		log.Fatalf("Failed to create HTTP agent: %v", err)
+		fatal("failed to create HTTP agent", err)
 	}
 
 	// Setup Gin
 	if os.Getenv("GIN_MODE") == "" {
 		gin.SetMode(gin.ReleaseMode)
 	}
-	router := gin.Default()
+	router := gin.New()
+	// Client addresses key the rate limits, so forwarded headers count only from known proxies
+	if err := router.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		fatal("failed to setup trusted proxies", err)
+	}
+	router.Use(otelgin.Middleware(config.Tracing.ServiceName), handlers.RequestLogger(), gin.Recovery())
+
+	// Setup authentication
+	var authenticator *auth.Authenticator
+	if config.Auth.Enabled {
+		authenticator, err = auth.New(context.Background(), &config.Auth)
+		if err != nil {
+			fatal("failed to setup authentication", err)
+		}
+	} else {
+		slog.Warn("API authentication is disabled; anyone who can reach the server can use the agent")
+	}
 
 	// Setup handlers
-	h := handlers.NewHandler(httpAgent)
+	h := handlers.NewHandler(httpAgent, authenticator, handlers.NewRateLimiter(&config.Limits))
 	h.SetupRoutes(router)
 
 	// Create server
@@ -53,11 +82,10 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Printf("Starting HTTP Agent on %s", addr)
-		log.Printf("LLM Provider: %s (Model: %s)", config.LLM.Provider, config.LLM.Model)
-		log.Printf("Open http://localhost:%s in your browser", config.Server.Port)
+		slog.Info("starting HTTP Agent", "addr", addr, "llm_provider", config.LLM.Provider, "llm_model", config.LLM.Model,
+			"url", fmt.Sprintf("http://localhost:%s", config.Server.Port))
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			fatal("failed to start server", err)
 		}
 	}()
 
@@ -65,16 +93,42 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("Shutting down server...")
+	slog.Info("shutting down server")
 
 	// Graceful shutdown with 5 second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("Server forced to shutdown:", err)
+		fatal("server forced to shutdown", err)
+	}
+	if err := httpAgent.Close(); err != nil {
+		slog.Error("failed to close agent", "error", err)
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
 	}
 
-	log.Println("Server exited")
+	slog.Info("server exited")
+}
+
+// fatal logs an error that prevents the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// logProvider logs the LLM provider when it needs no API key
+func logProvider(config *models.Config) {
+	provider := strings.ToLower(config.LLM.Provider)
+	if !requiresAPIKey(provider) {
+		slog.Info("using LLM provider without API key", "provider", config.LLM.Provider)
+	}
+}
+
+// requiresAPIKey reports whether a provider is a cloud service that needs an API key
+func requiresAPIKey(provider string) bool {
+	return provider == "openai" || provider == "anthropic" || provider == "claude" || provider == "gemini" || provider == "google" ||
+		provider == "mistral" || provider == "cohere"
 }
 
 func loadConfig() (*models.Config, error) {
@@ -97,6 +151,41 @@ func loadConfig() (*models.Config, error) {
 	viper.SetDefault("http.max_response_size", 10485760) // 10MB
 	viper.SetDefault("http.block_private_ips", false)
 
+	viper.SetDefault("session.ttl", 30)
+	viper.SetDefault("session.max_sessions", 100)
+
+	viper.SetDefault("history.enabled", true)
+	viper.SetDefault("history.path", "data/history.db")
+
+	viper.SetDefault("agent.max_steps", 5)
+
+	viper.SetDefault("monitor.max_runs", 100)
+	viper.SetDefault("monitor.smtp.port", 587)
+
+	viper.SetDefault("load_test.enabled", false)
+	viper.SetDefault("load_test.max_concurrency", 20)
+	viper.SetDefault("load_test.max_requests", 1000)
+	viper.SetDefault("load_test.max_duration", 60)
+
+	viper.SetDefault("audit.enabled", true)
+	viper.SetDefault("audit.path", "data/audit.log")
+
+	viper.SetDefault("auth.enabled", false)
+	viper.SetDefault("auth.oidc.role_claim", "roles")
+
+	viper.SetDefault("limits.requests_per_minute", 120)
+	viper.SetDefault("limits.burst", 20)
+	viper.SetDefault("limits.max_concurrent_requests", 50)
+	viper.SetDefault("limits.max_concurrent_llm_calls", 10)
+	viper.SetDefault("limits.queue_timeout", 5)
+
+	viper.SetDefault("logging.level", "info")
+	viper.SetDefault("logging.format", "json")
+
+	viper.SetDefault("tracing.enabled", false)
+	viper.SetDefault("tracing.service_name", "http-agent")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+
 	// Config file
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -116,27 +205,66 @@ func loadConfig() (*models.Config, error) {
 
 	// Bind specific environment variables
 	viper.BindEnv("server.port", "PORT")
+	viper.BindEnv("server.trusted_proxies", "TRUSTED_PROXIES")
 	viper.BindEnv("llm.provider", "LLM_PROVIDER")
-	viper.BindEnv("llm.api_key", "LLM_API_KEY", "OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY", "GOOGLE_API_KEY")
+	viper.BindEnv("llm.api_key", "LLM_API_KEY", "OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY", "GOOGLE_API_KEY", "MISTRAL_API_KEY", "COHERE_API_KEY")
 	viper.BindEnv("llm.model", "LLM_MODEL")
 	viper.BindEnv("llm.base_url", "HTTP_AGENT_LLM_BASE_URL")
+	viper.BindEnv("llm.keep_alive", "OLLAMA_KEEP_ALIVE")
+	viper.BindEnv("llm.num_ctx", "OLLAMA_NUM_CTX")
+	viper.BindEnv("llm.name", "LLM_NAME")
+	viper.BindEnv("llm.input_cost_per_million", "LLM_INPUT_COST_PER_MILLION")
+	viper.BindEnv("llm.output_cost_per_million", "LLM_OUTPUT_COST_PER_MILLION")
 	viper.BindEnv("llm.model", "LLM_MODEL")
 	viper.BindEnv("http.timeout", "HTTP_TIMEOUT")
 	viper.BindEnv("http.verify_ssl", "VERIFY_SSL")
 	viper.BindEnv("http.block_private_ips", "BLOCK_PRIVATE_IPS")
+	viper.BindEnv("http.ca_bundle", "CA_BUNDLE")
+	viper.BindEnv("http.policy.allowed_hosts", "TARGET_ALLOWED_HOSTS")
+	viper.BindEnv("http.policy.denied_hosts", "TARGET_DENIED_HOSTS")
+	viper.BindEnv("http.policy.allowed_ports", "TARGET_ALLOWED_PORTS")
+	viper.BindEnv("http.policy.denied_ports", "TARGET_DENIED_PORTS")
+	viper.BindEnv("history.path", "HISTORY_PATH")
+	viper.BindEnv("prompts.dir", "PROMPTS_DIR")
+	viper.BindEnv("audit.enabled", "AUDIT_LOG_ENABLED")
+	viper.BindEnv("audit.path", "AUDIT_LOG_PATH")
+	viper.BindEnv("auth.enabled", "AUTH_ENABLED")
+	viper.BindEnv("auth.oidc.issuer", "AUTH_OIDC_ISSUER")
+	viper.BindEnv("auth.oidc.audience", "AUTH_OIDC_AUDIENCE")
+	viper.BindEnv("auth.oidc.role_claim", "AUTH_OIDC_ROLE_CLAIM")
+	viper.BindEnv("limits.requests_per_minute", "RATE_LIMIT_PER_MINUTE")
+	viper.BindEnv("limits.burst", "RATE_LIMIT_BURST")
+	viper.BindEnv("limits.max_concurrent_requests", "MAX_CONCURRENT_REQUESTS")
+	viper.BindEnv("limits.max_concurrent_llm_calls", "MAX_CONCURRENT_LLM_CALLS")
+	viper.BindEnv("limits.queue_timeout", "LIMITS_QUEUE_TIMEOUT")
+	viper.BindEnv("dns.resolvers", "DNS_RESOLVERS")
+	viper.BindEnv("logging.level", "LOG_LEVEL")
+	viper.BindEnv("logging.format", "LOG_FORMAT")
+	viper.BindEnv("tracing.enabled", "TRACING_ENABLED")
+	viper.BindEnv("tracing.endpoint", "HTTP_AGENT_TRACING_ENDPOINT", "OTEL_EXPORTER_OTLP_ENDPOINT")
+	viper.BindEnv("tracing.service_name", "HTTP_AGENT_TRACING_SERVICE_NAME", "OTEL_SERVICE_NAME")
 
 	var config models.Config
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, err
 	}
 
+	// API keys from the environment, as comma-separated name:role:key entries
+	if keys := os.Getenv("AUTH_API_KEYS"); keys != "" {
+		for _, entry := range strings.Split(keys, ",") {
+			parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+			if len(parts) != 3 {
+				return nil, fmt.Errorf("invalid AUTH_API_KEYS entry %q (expected name:role:key)", entry)
+			}
+			config.Auth.APIKeys = append(config.Auth.APIKeys, models.APIKey{Name: parts[0], Role: parts[1], Key: parts[2]})
+		}
+	}
+
 	// Validate required fields - API key needed for cloud providers only
 	provider := strings.ToLower(config.LLM.Provider)
-	requiresAPIKey := provider == "openai" || provider == "anthropic" || provider == "claude" || provider == "gemini" || provider == "google"
 
-	// Local providers (ollama, lmstudio) don't require API keys
-	if !requiresAPIKey {
-		log.Printf("Using local LLM provider: %s (no API key required)", config.LLM.Provider)
+	// Local providers (ollama, lmstudio) don't require API keys; it is optional for openai-compatible
+	if !requiresAPIKey(provider) {
 		return &config, nil
 	}
 
@@ -164,8 +292,20 @@ func loadConfig() (*models.Config, error) {
 			} else {
 				return nil, fmt.Errorf("provider '%s' requires an API key. Set GEMINI_API_KEY or GOOGLE_API_KEY environment variable", config.LLM.Provider)
 			}
+		case "mistral":
+			if key := os.Getenv("MISTRAL_API_KEY"); key != "" {
+				config.LLM.APIKey = key
+			} else {
+				return nil, fmt.Errorf("provider '%s' requires an API key. Set MISTRAL_API_KEY environment variable", config.LLM.Provider)
+			}
+		case "cohere":
+			if key := os.Getenv("COHERE_API_KEY"); key != "" {
+				config.LLM.APIKey = key
+			} else {
+				return nil, fmt.Errorf("provider '%s' requires an API key. Set COHERE_API_KEY environment variable", config.LLM.Provider)
+			}
 		default:
-			return nil, fmt.Errorf("unknown cloud provider '%s'. Supported: openai, anthropic, gemini, ollama, lmstudio", config.LLM.Provider)
+			return nil, fmt.Errorf("unknown cloud provider '%s'. Supported: openai, anthropic, gemini, mistral, cohere, ollama, lmstudio, openai-compatible", config.LLM.Provider)
 		}
 	}
 
@@ -3,40 +3,181 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"strings"
+	"time"
 
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/audit"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/history"
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
 )
 
+// ErrHistoryDisabled is returned by history operations when persistence is turned off
+var ErrHistoryDisabled = errors.New("request history is disabled")
+
+// ErrAuditDisabled is returned by audit log operations when the audit log is turned off
+var ErrAuditDisabled = errors.New("audit log is disabled")
+
 // HTTPAgent combines HTTP client and LLM for intelligent request analysis
 type HTTPAgent struct {
-	httpClient *HTTPClient
-	llmClient  LLMClient
+	httpClient   *HTTPClient
+	llmClient    LLMClient
+	providers    []*llmProvider // The primary provider first, then the comparison providers
+	resolvers    []*dnsResolver // Resolvers of the DNS diagnostics; empty uses the system resolver
+	sessions     *SessionStore
+	history      *history.Store // nil when history is disabled
+	audit        *audit.Log     // nil when the audit log is disabled
+	specs        *SpecStore
+	environments *EnvironmentStore
+	monitors     *MonitorStore
+	loadTest     models.LoadTestConfig
+	prompts      *PromptTemplates
+	maxSteps     int // Follow-up request budget for investigations
 }
 
 // NewHTTPAgent creates a new HTTP agent
-func NewHTTPAgent(httpConfig *models.HTTPConfig, llmConfig *models.LLMConfig) (*HTTPAgent, error) {
-	httpClient := NewHTTPClient(httpConfig)
+func NewHTTPAgent(config *models.Config) (*HTTPAgent, error) {
+	httpClient, err := NewHTTPClient(&config.HTTP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
 
-	llmClient, err := NewLLMClient(llmConfig)
+	llmClient, err := NewLLMClient(&config.LLM)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create LLM client: %w", err)
 	}
 
+	prompts, err := LoadPromptTemplates(config.Prompts.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var historyStore *history.Store
+	if config.History.Enabled {
+		historyStore, err = history.NewStore(config.History.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open history store: %w", err)
+		}
+	}
+
+	var auditLog *audit.Log
+	if config.Audit.Enabled {
+		auditLog, err = audit.Open(config.Audit.Path)
+		if err != nil {
+			return nil, err
+		}
+		httpClient.audit = auditLog
+	}
+
+	// Cap concurrent outbound requests and LLM calls across all callers
+	timeout := queueTimeout(&config.Limits)
+	httpClient.limiter = newConcurrencyLimiter("outbound request", config.Limits.MaxConcurrentRequests, timeout)
+	llmLimiter := newConcurrencyLimiter("LLM call", config.Limits.MaxConcurrentLLMCalls, timeout)
+	if llmLimiter != nil {
+		llmClient = &limitedLLMClient{LLMClient: llmClient, limiter: llmLimiter}
+	}
+
+	primary := newLLMProvider(&config.LLM, llmClient, llmLimiter)
+	if primary.name == "" {
+		primary.name = primaryProviderName
+	}
+	comparison, err := newComparisonProviders(primary.name, config.Comparison.Providers, llmLimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers, err := newDNSResolvers(&config.DNS)
+	if err != nil {
+		return nil, err
+	}
+
+	maxSteps := config.Agent.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultInvestigationSteps
+	}
+
 	return &HTTPAgent{
-		httpClient: httpClient,
-		llmClient:  llmClient,
+		httpClient:   httpClient,
+		llmClient:    llmClient,
+		providers:    append([]*llmProvider{primary}, comparison...),
+		resolvers:    resolvers,
+		sessions:     NewSessionStore(&config.Session),
+		history:      historyStore,
+		audit:        auditLog,
+		specs:        NewSpecStore(),
+		environments: NewEnvironmentStore(config.Environments),
+		monitors:     NewMonitorStore(&config.Monitor),
+		loadTest:     config.LoadTest,
+		prompts:      prompts,
+		maxSteps:     maxSteps,
 	}, nil
 }
 
+// Close releases resources held by the agent
+func (a *HTTPAgent) Close() error {
+	a.monitors.Stop()
+	if a.audit != nil {
+		if err := a.audit.Close(); err != nil {
+			return err
+		}
+	}
+	if a.history != nil {
+		return a.history.Close()
+	}
+	return nil
+}
+
 // Execute performs an HTTP request and analyzes it with AI
 func (a *HTTPAgent) Execute(ctx context.Context, reqConfig *models.RequestConfig) (*models.AnalysisResult, error) {
-	// Perform DNS diagnostics
-	dnsDiag := PerformDNSDiagnostics(reqConfig.URL)
+	if err := a.prompts.Validate(reqConfig.Profile); err != nil {
+		return nil, err
+	}
+
+	if err := validateRequestAuth(reqConfig.Auth); err != nil {
+		return nil, err
+	}
+
+	// Resolve the compared providers before anything is sent
+	var compared []*llmProvider
+	if len(reqConfig.Compare) > 0 {
+		if reqConfig.Investigate {
+			return nil, fmt.Errorf("%w: investigations cannot be compared", ErrInvalidComparison)
+		}
+		var err error
+		if compared, err = a.comparedProviders(reqConfig.Compare); err != nil {
+			return nil, err
+		}
+	}
 
-	// Perform SSL diagnostics
-	sslDiag := PerformSSLDiagnostics(reqConfig.URL)
+	// Attach documented response schemas so the LLM can check the contract
+	if reqConfig.OpenAPI != nil {
+		if err := a.resolveOpenAPIReference(reqConfig.OpenAPI); err != nil {
+			return nil, err
+		}
+	}
+
+	// Shape GraphQL operations into a JSON POST request
+	if reqConfig.GraphQL != nil {
+		if err := prepareGraphQLRequest(reqConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	// Substitute environment variables; secrets are masked again once the request is sent
+	var secrets map[string]string
+	if reqConfig.Environment != "" {
+		var err error
+		if secrets, err = a.applyEnvironment(reqConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	if reqConfig.GraphQL != nil && reqConfig.GraphQL.Introspect {
+		reqConfig.GraphQL.Schema = a.introspectGraphQL(ctx, reqConfig)
+	}
 
 	// Determine if SSL verification was used
 	sslVerified := true
@@ -44,28 +185,108 @@ func (a *HTTPAgent) Execute(ctx context.Context, reqConfig *models.RequestConfig
 		sslVerified = *reqConfig.VerifySSL
 	}
 
-	// Make the HTTP request
-	response, err := a.httpClient.MakeRequest(ctx, reqConfig)
+	// Refuse targets the policy blocks before the diagnostics connect to them
+	var dnsDiag *models.DNSDiagnostics
+	var sslDiag *models.SSLCertificateDiagnostics
+	var reachability *models.ReachabilityReport
+	var response *models.Response
+	err := a.httpClient.validateURL(reqConfig.URL)
+	if err != nil {
+		err = fmt.Errorf("invalid URL: %w", err)
+		a.httpClient.auditRequest(ctx, "http", reqConfig, nil, err, time.Now())
+	} else {
+		// Perform DNS diagnostics
+		dnsDiag = PerformDNSDiagnostics(ctx, reqConfig.URL, a.resolvers)
+
+		// Perform SSL diagnostics
+		sslDiag = PerformSSLDiagnostics(ctx, reqConfig.URL, a.httpClient.dialContext, a.httpClient.rootCAs)
+
+		// Grade the server's TLS configuration when asked; it takes a couple dozen handshakes
+		if reqConfig.TLSReport && sslDiag.Present {
+			status := sslDiag.ExpiresIn
+			if sslDiag.Error != "" {
+				status = sslDiag.Error
+			}
+			sslDiag.TLS = a.httpClient.probeTLSConfiguration(ctx, reqConfig.URL, sslDiag.Valid, status)
+		}
+
+		// Probe the network path when asked, so network problems can be told from application ones
+		if reqConfig.Reachability != nil {
+			reachability = a.httpClient.probeReachability(ctx, reqConfig.URL, reqConfig.Reachability)
+			reqConfig.Reachability.Report = reachability
+		}
+
+		// Make the HTTP request
+		response, err = a.httpClient.MakeRequest(ctx, reqConfig)
+		if errors.Is(err, ErrBusy) {
+			// Nothing was sent; the caller should retry later
+			return nil, err
+		}
+	}
+
+	// Let the analysis weigh the server's TLS configuration
+	if response != nil && sslDiag != nil && sslDiag.TLS != nil {
+		response.TLS = sslDiag.TLS
+	}
+
+	// Keep secrets out of prompts, sessions and history from here on; investigation
+	// follow-ups still need the credentials
+	auth := reqConfig.Auth
+	maskRequest(reqConfig, secrets)
+	maskResponse(response, secrets)
+
+	// Check the caller's assertions; a failed request fails all of them
+	assertions := evaluateAssertions(reqConfig.Assertions, response)
+	var passed *bool
+	if assertions != nil {
+		ok := assertionsPassed(assertions)
+		passed = &ok
+	}
+
 	if err != nil {
-		return &models.AnalysisResult{
+		result := &models.AnalysisResult{
 			Request:        reqConfig,
 			Response:       nil,
-			Error:          err.Error(),
+			Error:          maskSecrets(err.Error(), secrets),
 			DNSDiagnostics: dnsDiag,
 			SSLDiagnostics: sslDiag,
+			Reachability:   reachability,
 			SSLVerified:    sslVerified,
-		}, nil
+			Assertions:     assertions,
+			Passed:         passed,
+		}
+		a.recordHistory(ctx, result)
+		return result, nil
 	}
 
 	// Format the response body if it's JSON
 	formattedBody := formatResponseBody(response)
 
 	// Analyze with LLM
-	analysis, err := a.llmClient.Analyze(ctx, reqConfig, response, reqConfig.Prompt)
+	var sessionID string
+	var analysis string
+	var steps []models.InvestigationStep
+	var comparison []models.ProviderAnswer
+	if reqConfig.Investigate {
+		analysis, steps, err = a.investigate(ctx, reqConfig, auth, response)
+	} else if compared != nil {
+		// The primary provider's answer doubles as the analysis
+		if comparison, err = a.compare(ctx, compared, reqConfig, response); err == nil {
+			analysis = comparison[0].Analysis
+			if comparison[0].Error != "" {
+				err = errors.New(comparison[0].Error)
+			}
+		}
+	} else {
+		analysis, err = a.analyze(ctx, reqConfig, response)
+	}
 	if err != nil {
 		// Return the response even if analysis fails
 		analysis = fmt.Sprintf("Analysis unavailable: %v\n\nBasic Info: Request returned %d %s in %s",
 			err, response.StatusCode, response.Status, FormatDuration(response.Duration))
+	} else if session, err := a.sessions.Create(reqConfig, response, userQuestion(reqConfig.Prompt), analysis); err == nil {
+		// Keep the exchange so the user can ask follow-up questions
+		sessionID = session.ID
 	}
 
 	result := &models.AnalysisResult{
@@ -76,12 +297,142 @@ func (a *HTTPAgent) Execute(ctx context.Context, reqConfig *models.RequestConfig
 		RequestDuration: FormatDuration(response.Duration),
 		DNSDiagnostics:  dnsDiag,
 		SSLDiagnostics:  sslDiag,
+		Reachability:    reachability,
 		SSLVerified:     sslVerified,
+		SessionID:       sessionID,
+		Investigation:   steps,
+		Assertions:      assertions,
+		Passed:          passed,
+		SecurityReport:  auditSecurityHeaders(reqConfig.URL, response),
+		Comparison:      comparison,
 	}
+	a.recordHistory(ctx, result)
 
 	return result, nil
 }
 
+// analyze asks the LLM about a response using the analysis profile selected by the request
+func (a *HTTPAgent) analyze(ctx context.Context, reqConfig *models.RequestConfig, response *models.Response) (string, error) {
+	messages, err := a.prompts.AnalysisMessages(reqConfig.Profile, reqConfig, response, reqConfig.Prompt)
+	if err != nil {
+		return "", err
+	}
+	return a.chat(ctx, reqConfig.Profile, messages)
+}
+
+// chat sends a conversation to the LLM with the system prompt of an analysis profile
+func (a *HTTPAgent) chat(ctx context.Context, profile string, messages []models.ChatMessage) (string, error) {
+	return a.chatWith(ctx, a.llmClient, profile, messages)
+}
+
+// chatWith sends a conversation to the given LLM client with the system prompt of an analysis profile
+func (a *HTTPAgent) chatWith(ctx context.Context, client LLMClient, profile string, messages []models.ChatMessage) (string, error) {
+	systemPrompt, err := a.prompts.System(profile)
+	if err != nil {
+		return "", err
+	}
+	return client.Chat(ctx, systemPrompt, messages)
+}
+
+// PromptProfiles returns the names of the available analysis profiles
+func (a *HTTPAgent) PromptProfiles() []string {
+	return a.prompts.Profiles()
+}
+
+// recordHistory persists the result if history is enabled; failures are logged, not returned
+func (a *HTTPAgent) recordHistory(ctx context.Context, result *models.AnalysisResult) {
+	if a.history == nil {
+		return
+	}
+
+	id, err := a.history.Save(ctx, result)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record request history", "error", err)
+		return
+	}
+	result.HistoryID = id
+}
+
+// ListHistory returns persisted requests matching the filter, newest first
+func (a *HTTPAgent) ListHistory(ctx context.Context, filter *models.HistoryFilter) ([]models.HistoryEntry, error) {
+	if a.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	return a.history.List(ctx, filter)
+}
+
+// GetHistoryEntry returns a persisted request with its full result
+func (a *HTTPAgent) GetHistoryEntry(ctx context.Context, id int64) (*models.HistoryEntry, error) {
+	if a.history == nil {
+		return nil, ErrHistoryDisabled
+	}
+	return a.history.Get(ctx, id)
+}
+
+// DeleteHistoryEntry removes a persisted request
+func (a *HTTPAgent) DeleteHistoryEntry(ctx context.Context, id int64) error {
+	if a.history == nil {
+		return ErrHistoryDisabled
+	}
+	return a.history.Delete(ctx, id)
+}
+
+// ListAudit returns the most recent audited outbound requests matching the filter, newest first
+func (a *HTTPAgent) ListAudit(filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	if a.audit == nil {
+		return nil, ErrAuditDisabled
+	}
+	return a.audit.List(filter)
+}
+
+// ExportAudit writes the audited outbound requests matching the filter as JSON Lines or CSV
+func (a *HTTPAgent) ExportAudit(w io.Writer, filter *models.AuditFilter) error {
+	if a.audit == nil {
+		return ErrAuditDisabled
+	}
+	return a.audit.Export(w, filter)
+}
+
+// FollowUp answers a follow-up question about a previously analyzed request
+// without re-issuing the HTTP request
+func (a *HTTPAgent) FollowUp(ctx context.Context, sessionID, question string) (*models.Session, error) {
+	session, err := a.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first user message carries the full request/response context,
+	// the remaining messages are replayed as-is
+	messages := append([]models.ChatMessage(nil), session.Messages...)
+	prompt, err := a.prompts.User(session.Request.Profile, session.Request, session.Response, messages[0].Content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer follow-up question: %w", err)
+	}
+	messages[0].Content = prompt
+	messages = append(messages, models.ChatMessage{Role: "user", Content: question})
+
+	client, err := a.sessionClient(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer follow-up question: %w", err)
+	}
+	answer, err := a.chatWith(ctx, client, session.Request.Profile, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to answer follow-up question: %w", err)
+	}
+
+	return a.sessions.AppendExchange(sessionID, question, answer)
+}
+
+// GetSession returns the conversation session with the given ID
+func (a *HTTPAgent) GetSession(sessionID string) (*models.Session, error) {
+	return a.sessions.Get(sessionID)
+}
+
+// DeleteSession discards the conversation session with the given ID
+func (a *HTTPAgent) DeleteSession(sessionID string) error {
+	return a.sessions.Delete(sessionID)
+}
+
 // formatResponseBody attempts to pretty-print JSON response bodies
 func formatResponseBody(response *models.Response) string {
 	if response == nil || response.Body == "" {
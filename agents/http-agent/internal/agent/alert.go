@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// monitorAlertPayload is the JSON body posted to webhook alert destinations
+type monitorAlertPayload struct {
+	Event   string             `json:"event"` // failing or recovered
+	Monitor monitorAlertTarget `json:"monitor"`
+	Run     *models.MonitorRun `json:"run"`
+	Text    string             `json:"text"`
+}
+
+// monitorAlertTarget identifies the monitor in an alert
+type monitorAlertTarget struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	Schedule string `json:"schedule"`
+}
+
+// validateAlerts checks that every alert destination is complete
+func (a *HTTPAgent) validateAlerts(alerts []models.MonitorAlert) error {
+	for i := range alerts {
+		alert := &alerts[i]
+		alert.Type = strings.ToLower(strings.TrimSpace(alert.Type))
+		switch alert.Type {
+		case "webhook", "slack":
+			if alert.URL == "" {
+				return fmt.Errorf("%s alerts require a url", alert.Type)
+			}
+		case "email":
+			if len(alert.To) == 0 {
+				return fmt.Errorf("email alerts require at least one recipient")
+			}
+			if a.monitors.smtp.Host == "" {
+				return fmt.Errorf("email alerts require monitor.smtp.host to be configured")
+			}
+		default:
+			return fmt.Errorf("unsupported alert type %q (use webhook, slack or email)", alert.Type)
+		}
+	}
+	return nil
+}
+
+// sendAlerts notifies every destination of a monitor and returns the delivery errors
+func (a *HTTPAgent) sendAlerts(ctx context.Context, monitor *models.Monitor, run *models.MonitorRun, recovered bool) []string {
+	subject, text := alertMessage(monitor, run, recovered)
+
+	var errs []string
+	for _, alert := range monitor.Alerts {
+		var err error
+		switch alert.Type {
+		case "webhook":
+			event := "failing"
+			if recovered {
+				event = "recovered"
+			}
+			err = a.postAlert(ctx, alert.URL, monitorAlertPayload{
+				Event: event,
+				Monitor: monitorAlertTarget{
+					ID:       monitor.ID,
+					Name:     monitor.Name,
+					Method:   monitor.Request.Method,
+					URL:      monitor.Request.URL,
+					Schedule: monitor.Schedule,
+				},
+				Run:  run,
+				Text: text,
+			})
+		case "slack":
+			err = a.postAlert(ctx, alert.URL, map[string]string{"text": "*" + subject + "*\n" + text})
+		case "email":
+			err = sendAlertEmail(&a.monitors.smtp, alert.To, subject, text)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s alert failed: %v", alert.Type, err))
+		}
+	}
+	return errs
+}
+
+// alertMessage builds the subject and plain-text body of an alert
+func alertMessage(monitor *models.Monitor, run *models.MonitorRun, recovered bool) (string, string) {
+	if recovered {
+		subject := fmt.Sprintf("[RECOVERED] %s", monitor.Name)
+		text := fmt.Sprintf("%s %s is healthy again: status %d in %dms at %s.",
+			monitor.Request.Method, monitor.Request.URL, run.StatusCode, run.Latency, run.Time.Format("2006-01-02 15:04:05 MST"))
+		return subject, text
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s started failing at %s.\n", monitor.Request.Method, monitor.Request.URL, run.Time.Format("2006-01-02 15:04:05 MST")))
+	if run.Error != "" {
+		sb.WriteString(fmt.Sprintf("- Request failed: %s\n", run.Error))
+	}
+	for _, regression := range run.Regressions {
+		sb.WriteString(fmt.Sprintf("- %s\n", regression))
+	}
+	if run.Summary != "" {
+		sb.WriteString("\n" + run.Summary + "\n")
+	}
+
+	return fmt.Sprintf("[FAILING] %s", monitor.Name), sb.String()
+}
+
+// postAlert sends a JSON alert through the regular client so SSRF protections apply
+func (a *HTTPAgent) postAlert(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	response, err := a.httpClient.MakeRequest(ctx, &models.RequestConfig{
+		URL:     url,
+		Method:  "POST",
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    string(body),
+	})
+	if err != nil {
+		return err
+	}
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("server returned %s", response.Status)
+	}
+	return nil
+}
+
+// sendAlertEmail delivers a plain-text alert through the configured SMTP server
+func sendAlertEmail(config *models.SMTPConfig, to []string, subject, text string) error {
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	from := config.From
+	if from == "" {
+		from = config.Username
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	msg.WriteString("Subject: " + subject + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+
+	addr := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	return smtp.SendMail(addr, auth, from, to, []byte(msg.String()))
+}
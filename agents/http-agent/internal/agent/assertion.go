@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// evaluateAssertions checks every assertion against the response; a nil response fails them all
+func evaluateAssertions(assertions []models.Assertion, response *models.Response) []models.AssertionResult {
+	if len(assertions) == 0 {
+		return nil
+	}
+
+	results := make([]models.AssertionResult, 0, len(assertions))
+	for _, assertion := range assertions {
+		if assertion.Operator == "" {
+			assertion.Operator = "eq"
+		}
+		result := models.AssertionResult{Assertion: assertion}
+		if response == nil {
+			result.Message = "request failed"
+		} else {
+			evaluateAssertion(&result, response)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// assertionsPassed reports whether every assertion passed
+func assertionsPassed(results []models.AssertionResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// evaluateAssertion resolves the source value and applies the operator
+func evaluateAssertion(result *models.AssertionResult, response *models.Response) {
+	source := strings.TrimSpace(result.Source)
+	var actual string
+	var err error
+	switch source {
+	case "":
+		result.Message = "source is required"
+		return
+	case "latency":
+		actual = strconv.FormatInt(response.Duration.Milliseconds(), 10)
+	case "body":
+		actual = response.Body
+	default:
+		actual, err = extractValue(response, source)
+	}
+
+	switch result.Operator {
+	case "exists":
+		result.Passed = err == nil
+		if err != nil {
+			result.Message = err.Error()
+		}
+		result.Actual = actual
+		return
+	case "not_exists":
+		result.Passed = err != nil
+		if err == nil {
+			result.Actual = actual
+			result.Message = "value is present"
+		}
+		return
+	}
+	if err != nil {
+		result.Message = err.Error()
+		return
+	}
+	result.Actual = actual
+
+	passed, err := compareAssertion(actual, result.Operator, result.Expected)
+	if err != nil {
+		result.Message = err.Error()
+		return
+	}
+	result.Passed = passed
+	if !passed {
+		result.Message = fmt.Sprintf("expected %s %s %q", source, result.Operator, result.Expected)
+	}
+}
+
+// compareAssertion applies an operator; values are compared as numbers when both parse as numbers
+func compareAssertion(actual, operator, expected string) (bool, error) {
+	actualNumber, actualErr := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	expectedNumber, expectedErr := strconv.ParseFloat(strings.TrimSpace(expected), 64)
+	numeric := actualErr == nil && expectedErr == nil
+
+	switch operator {
+	case "eq", "ne":
+		equal := actual == expected
+		if numeric {
+			equal = actualNumber == expectedNumber
+		}
+		return equal == (operator == "eq"), nil
+	case "lt", "lte", "gt", "gte":
+		if !numeric {
+			return false, fmt.Errorf("%s needs numeric values, got %q and %q", operator, actual, expected)
+		}
+		switch operator {
+		case "lt":
+			return actualNumber < expectedNumber, nil
+		case "lte":
+			return actualNumber <= expectedNumber, nil
+		case "gt":
+			return actualNumber > expectedNumber, nil
+		default:
+			return actualNumber >= expectedNumber, nil
+		}
+	case "contains":
+		return strings.Contains(actual, expected), nil
+	case "matches":
+		pattern, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern: %w", err)
+		}
+		return pattern.MatchString(actual), nil
+	default:
+		return false, fmt.Errorf("unsupported operator %q", operator)
+	}
+}
+
+// describeAssertions formats assertion results for the LLM prompt
+func describeAssertions(results []models.AssertionResult) string {
+	var sb strings.Builder
+	for _, result := range results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s", status, strings.TrimSpace(fmt.Sprintf("%s %s %s", result.Source, result.Operator, result.Expected))))
+		if result.Actual != "" {
+			actual := result.Actual
+			if len(actual) > 200 {
+				actual = actual[:200] + "... (truncated)"
+			}
+			sb.WriteString(fmt.Sprintf(" (actual: %s)", actual))
+		}
+		if result.Message != "" && !result.Passed {
+			sb.WriteString(fmt.Sprintf(" — %s", result.Message))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrInvalidComparison is returned when a request asks to compare unknown providers
+var ErrInvalidComparison = errors.New("invalid provider comparison")
+
+// primaryProviderName labels the main LLM provider when it has no configured name
+const primaryProviderName = "default"
+
+// llmProvider is a configured LLM provider that can take part in comparisons
+type llmProvider struct {
+	name       string
+	model      string
+	client     LLMClient
+	inputCost  float64 // USD per million tokens
+	outputCost float64
+
+	config  models.LLMConfig
+	limiter *concurrencyLimiter
+	mu      sync.Mutex
+	clients map[string]LLMClient // Clients of other models selected for sessions, by model
+}
+
+// newLLMProvider describes a provider and its client
+func newLLMProvider(config *models.LLMConfig, client LLMClient, limiter *concurrencyLimiter) *llmProvider {
+	return &llmProvider{
+		name:       config.Name,
+		model:      config.Model,
+		client:     client,
+		inputCost:  config.InputCostPerMillion,
+		outputCost: config.OutputCostPerMillion,
+		config:     *config,
+		limiter:    limiter,
+		clients:    make(map[string]LLMClient),
+	}
+}
+
+// newComparisonProviders creates the clients of the comparison providers, which need unique names
+func newComparisonProviders(primary string, configs []models.LLMConfig, limiter *concurrencyLimiter) ([]*llmProvider, error) {
+	names := map[string]bool{primary: true, "all": true}
+	providers := make([]*llmProvider, 0, len(configs))
+	for i := range configs {
+		config := &configs[i]
+		if config.Name == "" {
+			return nil, fmt.Errorf("comparison provider %d needs a name", i+1)
+		}
+		if names[config.Name] {
+			return nil, fmt.Errorf("comparison provider name %q is reserved or used twice", config.Name)
+		}
+		names[config.Name] = true
+
+		client, err := newLimitedLLMClient(config, limiter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create comparison provider %q: %w", config.Name, err)
+		}
+		providers = append(providers, newLLMProvider(config, client, limiter))
+	}
+	return providers, nil
+}
+
+// comparedProviders resolves the names a request asks to compare, always starting with the primary
+func (a *HTTPAgent) comparedProviders(names []string) ([]*llmProvider, error) {
+	selected := []*llmProvider{a.providers[0]}
+	seen := map[string]bool{a.providers[0].name: true}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, "all") {
+			for _, provider := range a.providers[1:] {
+				if !seen[provider.name] {
+					seen[provider.name] = true
+					selected = append(selected, provider)
+				}
+			}
+			continue
+		}
+		provider := a.provider(name)
+		if provider == nil {
+			return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidComparison, name)
+		}
+		if !seen[provider.name] {
+			seen[provider.name] = true
+			selected = append(selected, provider)
+		}
+	}
+	if len(selected) < 2 {
+		return nil, fmt.Errorf("%w: no comparison providers are configured", ErrInvalidComparison)
+	}
+	return selected, nil
+}
+
+// provider returns the configured provider with the given name, or nil
+func (a *HTTPAgent) provider(name string) *llmProvider {
+	for _, provider := range a.providers {
+		if provider.name == name {
+			return provider
+		}
+	}
+	return nil
+}
+
+// compare sends the same analysis to several providers in parallel and collects their answers
+// in the order given
+func (a *HTTPAgent) compare(ctx context.Context, providers []*llmProvider, reqConfig *models.RequestConfig, response *models.Response) ([]models.ProviderAnswer, error) {
+	systemPrompt, err := a.prompts.System(reqConfig.Profile)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := a.prompts.AnalysisMessages(reqConfig.Profile, reqConfig, response, reqConfig.Prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := make([]models.ProviderAnswer, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answers[i] = provider.answer(ctx, systemPrompt, messages)
+		}()
+	}
+	wg.Wait()
+	return answers, nil
+}
+
+// answer asks the provider for its analysis, measuring latency, tokens and cost
+func (p *llmProvider) answer(ctx context.Context, systemPrompt string, messages []models.ChatMessage) models.ProviderAnswer {
+	ctx, usage := withTokenUsage(ctx)
+	start := time.Now()
+	analysis, err := p.client.Chat(ctx, systemPrompt, messages)
+	latency := time.Since(start)
+
+	answer := models.ProviderAnswer{
+		Provider:  p.name,
+		Model:     p.model,
+		Analysis:  analysis,
+		Latency:   FormatDuration(latency),
+		LatencyMs: latency.Milliseconds(),
+	}
+	if err != nil {
+		answer.Error = err.Error()
+	}
+	answer.InputTokens, answer.OutputTokens = usage.totals()
+	if p.inputCost > 0 || p.outputCost > 0 {
+		cost := (float64(answer.InputTokens)*p.inputCost + float64(answer.OutputTokens)*p.outputCost) / 1e6
+		answer.Cost = &cost
+	}
+	return answer
+}
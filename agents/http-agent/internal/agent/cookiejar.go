@@ -0,0 +1,369 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+var (
+	// ErrCookieJarNotFound is returned when a named cookie jar does not exist
+	ErrCookieJarNotFound = errors.New("cookie jar not found")
+
+	// ErrCookieNotFound is returned when a cookie does not exist in a jar
+	ErrCookieNotFound = errors.New("cookie not found")
+)
+
+const (
+	// maxJarCookies limits the cookies kept per jar; further cookies are ignored
+	maxJarCookies = 300
+
+	// redactedCookieValue replaces cookie values in LLM prompts
+	redactedCookieValue = "[redacted]"
+)
+
+// CookieJarStore keeps named cookie jars in memory so requests that select the same jar
+// share cookies, e.g. a login request followed by authenticated calls
+type CookieJarStore struct {
+	mu   sync.Mutex
+	jars map[string]*CookieJar
+}
+
+// CookieJar is an http.CookieJar whose cookies can be listed and edited
+type CookieJar struct {
+	mu      sync.Mutex
+	cookies map[string]*models.Cookie // Keyed by domain, path and name
+}
+
+// NewCookieJarStore creates an empty cookie jar store
+func NewCookieJarStore() *CookieJarStore {
+	return &CookieJarStore{jars: make(map[string]*CookieJar)}
+}
+
+// jar returns the named jar, creating it on first use
+func (s *CookieJarStore) jar(name string) *CookieJar {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jar, ok := s.jars[name]
+	if !ok {
+		jar = &CookieJar{cookies: make(map[string]*models.Cookie)}
+		s.jars[name] = jar
+	}
+	return jar
+}
+
+// get returns an existing jar
+func (s *CookieJarStore) get(name string) (*CookieJar, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jar, ok := s.jars[name]
+	if !ok {
+		return nil, ErrCookieJarNotFound
+	}
+	return jar, nil
+}
+
+// SetCookies stores the cookies of a response, following the RFC 6265 domain, path and expiry rules
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := strings.ToLower(u.Hostname())
+	now := time.Now()
+	for _, c := range cookies {
+		cookie := models.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: sameSiteName(c.SameSite),
+		}
+
+		domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+		if domain == "" {
+			cookie.Domain = host
+			cookie.HostOnly = true
+		} else if domainMatch(host, domain) {
+			cookie.Domain = domain
+		} else {
+			// Servers may not set cookies for unrelated domains
+			continue
+		}
+		if !strings.HasPrefix(cookie.Path, "/") {
+			cookie.Path = defaultCookiePath(u.Path)
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			cookie.Expires = &now
+		case c.MaxAge > 0:
+			expires := now.Add(time.Duration(c.MaxAge) * time.Second)
+			cookie.Expires = &expires
+		case !c.Expires.IsZero():
+			expires := c.Expires
+			cookie.Expires = &expires
+		}
+
+		j.store(&cookie, now)
+	}
+}
+
+// Cookies returns the cookies to send with a request to the URL, most specific path first
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	host := strings.ToLower(u.Hostname())
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	now := time.Now()
+
+	var matches []*models.Cookie
+	for key, cookie := range j.cookies {
+		if cookie.Expires != nil && !cookie.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		if cookie.HostOnly && host != cookie.Domain || !cookie.HostOnly && !domainMatch(host, cookie.Domain) {
+			continue
+		}
+		if !pathMatch(path, cookie.Path) || cookie.Secure && !secure {
+			continue
+		}
+		matches = append(matches, cookie)
+	}
+	sort.Slice(matches, func(i, k int) bool { return len(matches[i].Path) > len(matches[k].Path) })
+
+	cookies := make([]*http.Cookie, len(matches))
+	for i, cookie := range matches {
+		cookies[i] = &http.Cookie{Name: cookie.Name, Value: cookie.Value}
+	}
+	return cookies
+}
+
+// store adds or replaces a cookie, or removes it when it has already expired
+func (j *CookieJar) store(cookie *models.Cookie, now time.Time) {
+	key := cookie.Domain + ";" + cookie.Path + ";" + cookie.Name
+	if cookie.Expires != nil && !cookie.Expires.After(now) {
+		delete(j.cookies, key)
+		return
+	}
+	if _, ok := j.cookies[key]; !ok && len(j.cookies) >= maxJarCookies {
+		return
+	}
+	j.cookies[key] = cookie
+}
+
+// list returns the unexpired cookies sorted by domain, path and name
+func (j *CookieJar) list() []models.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	cookies := make([]models.Cookie, 0, len(j.cookies))
+	for key, cookie := range j.cookies {
+		if cookie.Expires != nil && !cookie.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		cookies = append(cookies, *cookie)
+	}
+	sort.Slice(cookies, func(i, k int) bool {
+		if cookies[i].Domain != cookies[k].Domain {
+			return cookies[i].Domain < cookies[k].Domain
+		}
+		if cookies[i].Path != cookies[k].Path {
+			return cookies[i].Path < cookies[k].Path
+		}
+		return cookies[i].Name < cookies[k].Name
+	})
+	return cookies
+}
+
+// ListCookieJars returns all cookie jars sorted by name
+func (a *HTTPAgent) ListCookieJars() []models.CookieJarSummary {
+	store := a.httpClient.cookieJars
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	jars := make([]models.CookieJarSummary, 0, len(store.jars))
+	for name, jar := range store.jars {
+		jars = append(jars, models.CookieJarSummary{Name: name, Cookies: len(jar.list())})
+	}
+	sort.Slice(jars, func(i, j int) bool { return jars[i].Name < jars[j].Name })
+	return jars
+}
+
+// GetCookieJar returns the cookies stored in a jar
+func (a *HTTPAgent) GetCookieJar(name string) ([]models.Cookie, error) {
+	jar, err := a.httpClient.cookieJars.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return jar.list(), nil
+}
+
+// SetCookie adds or replaces a cookie in a jar, creating the jar if needed
+func (a *HTTPAgent) SetCookie(name string, cookie *models.Cookie) (*models.Cookie, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("cookie jar name is required")
+	}
+	if strings.ContainsAny(cookie.Name, "=; \t\r\n") || cookie.Name == "" {
+		return nil, fmt.Errorf("invalid cookie name %q", cookie.Name)
+	}
+	cookie.Domain = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(cookie.Domain)), ".")
+	if cookie.Domain == "" {
+		return nil, fmt.Errorf("cookie domain is required")
+	}
+	if !strings.HasPrefix(cookie.Path, "/") {
+		cookie.Path = "/"
+	}
+	switch strings.ToLower(cookie.SameSite) {
+	case "":
+	case "lax", "strict", "none":
+		cookie.SameSite = strings.ToUpper(cookie.SameSite[:1]) + strings.ToLower(cookie.SameSite[1:])
+	default:
+		return nil, fmt.Errorf("invalid same_site %q (use Lax, Strict or None)", cookie.SameSite)
+	}
+
+	jar := a.httpClient.cookieJars.jar(name)
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	stored := *cookie
+	jar.store(&stored, time.Now())
+	return cookie, nil
+}
+
+// DeleteCookie removes a cookie from a jar
+func (a *HTTPAgent) DeleteCookie(name, domain, path, cookieName string) error {
+	jar, err := a.httpClient.cookieJars.get(name)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	jar.mu.Lock()
+	defer jar.mu.Unlock()
+	key := strings.TrimPrefix(strings.ToLower(domain), ".") + ";" + path + ";" + cookieName
+	if _, ok := jar.cookies[key]; !ok {
+		return ErrCookieNotFound
+	}
+	delete(jar.cookies, key)
+	return nil
+}
+
+// DeleteCookieJar removes a jar and all of its cookies
+func (a *HTTPAgent) DeleteCookieJar(name string) error {
+	store := a.httpClient.cookieJars
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if _, ok := store.jars[name]; !ok {
+		return ErrCookieJarNotFound
+	}
+	delete(store.jars, name)
+	return nil
+}
+
+// cookieJar returns the jar selected by a request, or nil when none is selected
+func (c *HTTPClient) cookieJar(name string) http.CookieJar {
+	if name == "" {
+		return nil
+	}
+	return c.cookieJars.jar(name)
+}
+
+// domainMatch reports whether host is the cookie domain or one of its subdomains
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch reports whether a request path is within the cookie path
+func pathMatch(requestPath, cookiePath string) bool {
+	if requestPath == cookiePath {
+		return true
+	}
+	return strings.HasPrefix(requestPath, cookiePath) &&
+		(strings.HasSuffix(cookiePath, "/") || requestPath[len(cookiePath)] == '/')
+}
+
+// defaultCookiePath returns the directory of the request path, as used for cookies without a Path
+func defaultCookiePath(requestPath string) string {
+	i := strings.LastIndex(requestPath, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return requestPath[:i]
+}
+
+// sameSiteName returns the attribute value of a SameSite mode
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	default:
+		return ""
+	}
+}
+
+// redactCookieHeaders returns a copy of headers with Cookie and Set-Cookie values redacted
+// so session identifiers never reach the LLM
+func redactCookieHeaders(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		copied := make([]string, len(values))
+		for i, value := range values {
+			copied[i] = redactCookieHeader(name, value)
+		}
+		redacted[name] = copied
+	}
+	return redacted
+}
+
+// redactCookieHeader replaces the cookie values of a Cookie or Set-Cookie header, keeping
+// cookie names and attributes; other headers are returned unchanged
+func redactCookieHeader(name, value string) string {
+	switch {
+	case strings.EqualFold(name, "Cookie"):
+		pairs := strings.Split(value, ";")
+		for i, pair := range pairs {
+			if cookieName, _, ok := strings.Cut(strings.TrimSpace(pair), "="); ok {
+				pairs[i] = cookieName + "=" + redactedCookieValue
+			}
+		}
+		return strings.Join(pairs, "; ")
+	case strings.EqualFold(name, "Set-Cookie"):
+		pair, attributes, _ := strings.Cut(value, ";")
+		if cookieName, _, ok := strings.Cut(pair, "="); ok {
+			pair = strings.TrimSpace(cookieName) + "=" + redactedCookieValue
+		}
+		if attributes != "" {
+			return pair + ";" + attributes
+		}
+		return pair
+	default:
+		return value
+	}
+}
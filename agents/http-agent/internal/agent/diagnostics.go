@@ -1,19 +1,22 @@
 package agent
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"net"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
 )
 
-// PerformDNSDiagnostics performs DNS lookup for the given URL
-func PerformDNSDiagnostics(rawURL string) *models.DNSDiagnostics {
+// PerformDNSDiagnostics performs DNS lookup for the given URL, querying its A, AAAA, CNAME,
+// MX, TXT and NS records through the given resolvers (the system resolver when there are none)
+func PerformDNSDiagnostics(ctx context.Context, rawURL string, resolvers []*dnsResolver) *models.DNSDiagnostics {
 	startTime := time.Now()
 	diag := &models.DNSDiagnostics{}
 
@@ -34,25 +37,62 @@ func PerformDNSDiagnostics(rawURL string) *models.DNSDiagnostics {
 		return diag
 	}
 
-	// Perform DNS lookup
-	ips, err := net.LookupIP(hostname)
-	if err != nil {
-		diag.Error = fmt.Sprintf("DNS lookup failed: %v", err)
+	// An IP address has no records to look up
+	if ip := net.ParseIP(hostname); ip != nil {
+		diag.IPAddresses = []string{ip.String()}
 		diag.LookupTime = FormatDuration(time.Since(startTime))
 		return diag
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+	defer cancel()
+
+	// Perform DNS lookup; the request itself goes through the system resolver
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", hostname)
+	if err != nil {
+		diag.Error = fmt.Sprintf("DNS lookup failed: %v", err)
+		if len(resolvers) == 0 {
+			diag.LookupTime = FormatDuration(time.Since(startTime))
+			return diag
+		}
+	}
+
 	// Convert IPs to strings
 	for _, ip := range ips {
 		diag.IPAddresses = append(diag.IPAddresses, ip.String())
 	}
 
+	if len(resolvers) == 0 {
+		diag.Records = lookupSystemRecords(ctx, hostname, ips)
+		diag.LookupTime = FormatDuration(time.Since(startTime))
+		return diag
+	}
+
+	// Ask every resolver at once and compare their answers
+	results := make([]models.DNSResolverResult, len(resolvers))
+	var wg sync.WaitGroup
+	for i, resolver := range resolvers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = resolver.lookupRecords(ctx, hostname)
+		}()
+	}
+	wg.Wait()
+
+	diag.Records = results[0].Records
+	diag.Resolvers = results
+	if len(results) > 1 {
+		diag.Inconsistencies = compareResolvers(results)
+	}
+
 	diag.LookupTime = FormatDuration(time.Since(startTime))
 	return diag
 }
 
-// PerformSSLDiagnostics performs SSL/TLS certificate inspection
-func PerformSSLDiagnostics(rawURL string) *models.SSLCertificateDiagnostics {
+// PerformSSLDiagnostics performs SSL/TLS certificate inspection; dial opens the connection so the
+// caller's target checks apply, and rootCAs adds custom trusted roots (nil uses the system trust store)
+func PerformSSLDiagnostics(ctx context.Context, rawURL string, dial func(ctx context.Context, network, addr string) (net.Conn, error), rootCAs *x509.CertPool) *models.SSLCertificateDiagnostics {
 	diag := &models.SSLCertificateDiagnostics{
 		Present: false,
 	}
@@ -78,16 +118,20 @@ func PerformSSLDiagnostics(rawURL string) *models.SSLCertificateDiagnostics {
 
 	// Connect and get certificate
 	address := net.JoinHostPort(hostname, port)
-	conn, err := tls.DialWithDialer(
-		&net.Dialer{Timeout: 10 * time.Second},
-		"tcp",
-		address,
-		&tls.Config{
-			InsecureSkipVerify: false, // We want to check the cert validity
-			ServerName:         hostname,
-		},
-	)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	rawConn, err := dial(ctx, "tcp", address)
 	if err != nil {
+		diag.Error = fmt.Sprintf("Failed to connect: %v", err)
+		return diag
+	}
+	conn := tls.Client(rawConn, &tls.Config{
+		InsecureSkipVerify: false, // We want to check the cert validity
+		ServerName:         hostname,
+		RootCAs:            rootCAs,
+	})
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
 		// Try to get more specific error information
 		if strings.Contains(err.Error(), "certificate") {
 			diag.Present = true
@@ -98,7 +142,6 @@ func PerformSSLDiagnostics(rawURL string) *models.SSLCertificateDiagnostics {
 		}
 		return diag
 	}
-	defer conn.Close()
 
 	// Get certificate chain
 	certs := conn.ConnectionState().PeerCertificates
@@ -169,6 +212,16 @@ func FormatDNSDiagnostics(diag *models.DNSDiagnostics) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("Hostname: %s\n", diag.Hostname))
 	sb.WriteString(fmt.Sprintf("IP Addresses: %s\n", strings.Join(diag.IPAddresses, ", ")))
+	for _, record := range diag.Records {
+		if record.TTL != nil {
+			sb.WriteString(fmt.Sprintf("%s %s (TTL %ds)\n", record.Type, record.Value, *record.TTL))
+		} else {
+			sb.WriteString(fmt.Sprintf("%s %s\n", record.Type, record.Value))
+		}
+	}
+	for _, inconsistency := range diag.Inconsistencies {
+		sb.WriteString(fmt.Sprintf("Inconsistent: %s\n", inconsistency))
+	}
 	sb.WriteString(fmt.Sprintf("Lookup Time: %s", diag.LookupTime))
 	return sb.String()
 }
@@ -191,6 +244,9 @@ func FormatSSLDiagnostics(diag *models.SSLCertificateDiagnostics) string {
 	}
 
 	sb.WriteString(diag.CertificateInfo)
+	if diag.TLS != nil {
+		sb.WriteString(fmt.Sprintf("\nTLS Configuration: %d/100 (grade %s)", diag.TLS.Score, diag.TLS.Grade))
+	}
 	return sb.String()
 }
 
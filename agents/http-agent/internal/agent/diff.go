@@ -0,0 +1,298 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrNoResponse is returned when a history entry to compare has no response
+var ErrNoResponse = errors.New("history entry has no response")
+
+const (
+	// maxDiffChanges limits the body changes reported in a diff
+	maxDiffChanges = 100
+
+	// maxDiffPaths limits how many JSON values are flattened per body
+	maxDiffPaths = 5000
+
+	// maxDiffValue truncates long values in reported changes
+	maxDiffValue = 200
+)
+
+// volatileHeaders change on every response and are left out of header diffs
+var volatileHeaders = map[string]bool{
+	"Date":            true,
+	"Age":             true,
+	"X-Request-Id":    true,
+	"X-Amzn-Trace-Id": true,
+	"Cf-Ray":          true,
+}
+
+// DiffResponses compares two stored responses, or the stored response with a fresh run
+// of the same request, and asks the LLM whether the changes look breaking
+func (a *HTTPAgent) DiffResponses(ctx context.Context, diffReq *models.DiffRequest) (*models.ResponseDiff, error) {
+	base, err := a.responseEntry(ctx, diffReq.BaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *models.AnalysisResult
+	targetID := diffReq.TargetID
+	if targetID != 0 {
+		if target, err = a.responseEntry(ctx, targetID); err != nil {
+			return nil, err
+		}
+	} else {
+		if target, err = a.rerun(ctx, base.Request); err != nil {
+			return nil, err
+		}
+		targetID = target.HistoryID
+	}
+
+	diff := &models.ResponseDiff{
+		BaseID:       diffReq.BaseID,
+		TargetID:     targetID,
+		StatusBefore: base.Response.StatusCode,
+		StatusAfter:  target.Response.StatusCode,
+		LatencyDelta: formatDelta(target.Response.Duration - base.Response.Duration),
+		Headers:      diffHeaders(base.Response.Headers, target.Response.Headers),
+	}
+	diff.Body, diff.Truncated = diffBodies(base.Response.Body, target.Response.Body)
+
+	analysis, err := a.chat(ctx, "", []models.ChatMessage{
+		{Role: "user", Content: buildDiffPrompt(base.Request, diff, diffReq.Prompt)},
+	})
+	if err != nil {
+		analysis = fmt.Sprintf("Analysis unavailable: %v", err)
+	}
+	diff.Analysis = analysis
+
+	return diff, nil
+}
+
+// responseEntry loads a history entry that has a response
+func (a *HTTPAgent) responseEntry(ctx context.Context, id int64) (*models.AnalysisResult, error) {
+	entry, err := a.GetHistoryEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Result == nil || entry.Result.Response == nil {
+		return nil, fmt.Errorf("%w: entry %d failed with %q", ErrNoResponse, id, entry.Error)
+	}
+	return entry.Result, nil
+}
+
+// rerun executes a stored request again and records it in the history
+func (a *HTTPAgent) rerun(ctx context.Context, stored *models.RequestConfig) (*models.AnalysisResult, error) {
+	reqConfig := *stored
+	reqConfig.Prompt = ""
+	reqConfig.OpenAPI = nil
+	reqConfig.Investigate = false
+	if stored.GraphQL != nil {
+		graphQL := *stored.GraphQL
+		reqConfig.GraphQL = &graphQL
+	}
+
+	response, err := a.executeRequest(ctx, &reqConfig)
+	result := &models.AnalysisResult{Request: &reqConfig, Response: response}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.RequestDuration = FormatDuration(response.Duration)
+	}
+	a.recordHistory(ctx, result)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to re-run request: %w", err)
+	}
+	return result, nil
+}
+
+// diffHeaders compares response headers, ignoring values that change on every response
+func diffHeaders(before, after map[string][]string) []models.DiffChange {
+	names := make(map[string]bool)
+	for name := range before {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+	for name := range after {
+		names[http.CanonicalHeaderKey(name)] = true
+	}
+
+	changes := []models.DiffChange{}
+	for name := range names {
+		if volatileHeaders[name] {
+			continue
+		}
+		oldValue, hadOld := headerValue(before, name)
+		newValue, hasNew := headerValue(after, name)
+		switch {
+		case !hadOld:
+			changes = append(changes, models.DiffChange{Path: name, Type: "added", After: newValue})
+		case !hasNew:
+			changes = append(changes, models.DiffChange{Path: name, Type: "removed", Before: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, models.DiffChange{Path: name, Type: "changed", Before: oldValue, After: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// headerValue returns the joined values of a header, looked up case-insensitively
+func headerValue(headers map[string][]string, name string) (string, bool) {
+	for key, values := range headers {
+		if strings.EqualFold(key, name) {
+			return strings.Join(values, ", "), true
+		}
+	}
+	return "", false
+}
+
+// diffBodies compares JSON bodies value by value and other bodies as a whole
+func diffBodies(before, after string) ([]models.DiffChange, bool) {
+	oldValues, oldErr := flattenJSON(before)
+	newValues, newErr := flattenJSON(after)
+	if oldErr != nil || newErr != nil {
+		if before == after {
+			return []models.DiffChange{}, false
+		}
+		return []models.DiffChange{{
+			Path:   "body",
+			Type:   "changed",
+			Before: truncateDiffValue(before),
+			After:  truncateDiffValue(after),
+		}}, false
+	}
+
+	changes := []models.DiffChange{}
+	for path, oldValue := range oldValues {
+		newValue, ok := newValues[path]
+		switch {
+		case !ok:
+			changes = append(changes, models.DiffChange{Path: path, Type: "removed", Before: truncateDiffValue(oldValue)})
+		case newValue != oldValue:
+			changes = append(changes, models.DiffChange{Path: path, Type: "changed", Before: truncateDiffValue(oldValue), After: truncateDiffValue(newValue)})
+		}
+	}
+	for path, newValue := range newValues {
+		if _, ok := oldValues[path]; !ok {
+			changes = append(changes, models.DiffChange{Path: path, Type: "added", After: truncateDiffValue(newValue)})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+
+	if len(changes) > maxDiffChanges {
+		return changes[:maxDiffChanges], true
+	}
+	return changes, false
+}
+
+// flattenJSON maps the JSONPath of every leaf value (and empty object or array) to its JSON encoding
+func flattenJSON(body string) (map[string]string, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		if len(values) >= maxDiffPaths {
+			return
+		}
+		switch node := value.(type) {
+		case map[string]interface{}:
+			if len(node) == 0 {
+				values[path] = "{}"
+			}
+			for key, child := range node {
+				walk(path+"."+key, child)
+			}
+		case []interface{}:
+			if len(node) == 0 {
+				values[path] = "[]"
+			}
+			for i, child := range node {
+				walk(path+"["+strconv.Itoa(i)+"]", child)
+			}
+		default:
+			encoded, _ := json.Marshal(node)
+			values[path] = string(encoded)
+		}
+	}
+	walk("$", doc)
+	return values, nil
+}
+
+// truncateDiffValue shortens long values in reported changes
+func truncateDiffValue(value string) string {
+	if len(value) > maxDiffValue {
+		return value[:maxDiffValue] + "... (truncated)"
+	}
+	return value
+}
+
+// formatDelta formats a signed duration difference
+func formatDelta(d time.Duration) string {
+	if d < 0 {
+		return "-" + FormatDuration(-d)
+	}
+	return "+" + FormatDuration(d)
+}
+
+// buildDiffPrompt describes the differences between two responses for the LLM
+func buildDiffPrompt(request *models.RequestConfig, diff *models.ResponseDiff, question string) string {
+	var sb strings.Builder
+
+	sb.WriteString("Response Comparison:\n")
+	sb.WriteString(fmt.Sprintf("- Request: %s %s\n", request.Method, request.URL))
+	sb.WriteString(fmt.Sprintf("- Status: %d -> %d\n", diff.StatusBefore, diff.StatusAfter))
+	sb.WriteString(fmt.Sprintf("- Latency change: %s\n", diff.LatencyDelta))
+
+	writeChanges := func(title string, changes []models.DiffChange) {
+		if len(changes) == 0 {
+			sb.WriteString(fmt.Sprintf("\n%s: no changes\n", title))
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n%s:\n", title))
+		for _, change := range changes {
+			switch change.Type {
+			case "added":
+				sb.WriteString(fmt.Sprintf("+ %s: %s\n", change.Path, change.After))
+			case "removed":
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", change.Path, change.Before))
+			default:
+				sb.WriteString(fmt.Sprintf("~ %s: %s -> %s\n", change.Path, change.Before, change.After))
+			}
+		}
+	}
+	// Cookie values are session credentials and never reach the LLM
+	headers := make([]models.DiffChange, len(diff.Headers))
+	for i, change := range diff.Headers {
+		change.Before = redactCookieHeader(change.Path, change.Before)
+		change.After = redactCookieHeader(change.Path, change.After)
+		headers[i] = change
+	}
+	writeChanges("Header Changes", headers)
+	writeChanges("Body Changes", diff.Body)
+	if diff.Truncated {
+		sb.WriteString(fmt.Sprintf("(only the first %d body changes are listed)\n", maxDiffChanges))
+	}
+
+	if question == "" {
+		question = "Explain what changed between the two responses and whether the changes look breaking for API clients (removed fields, type changes, status or content-type changes) or are just data updates."
+	}
+	sb.WriteString(fmt.Sprintf("\nUser Question: %s\n", question))
+	sb.WriteString("\nProvide a clear and helpful answer:")
+
+	return sb.String()
+}
@@ -0,0 +1,361 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// dnsQueryTimeout bounds the record queries of one diagnosis
+	dnsQueryTimeout = 5 * time.Second
+	// dnsUDPSize is the EDNS0 UDP payload size advertised to resolvers
+	dnsUDPSize = 4096
+	// maxDNSMessageSize limits DNS-over-TCP and DNS-over-HTTPS answers
+	maxDNSMessageSize = 65535
+)
+
+// dnsRecordTypes are the record types queried for the diagnosed host
+var dnsRecordTypes = []dnsmessage.Type{
+	dnsmessage.TypeA, dnsmessage.TypeAAAA, dnsmessage.TypeCNAME, dnsmessage.TypeMX, dnsmessage.TypeTXT, dnsmessage.TypeNS,
+}
+
+// dnsResolver queries a DNS server directly (over UDP, falling back to TCP) or over HTTPS
+type dnsResolver struct {
+	name    string // As configured
+	address string // host:port for classic DNS
+	dohURL  string // DNS-over-HTTPS endpoint
+	client  *http.Client
+}
+
+// newDNSResolvers parses the configured resolver addresses and DNS-over-HTTPS URLs
+func newDNSResolvers(config *models.DNSConfig) ([]*dnsResolver, error) {
+	resolvers := make([]*dnsResolver, 0, len(config.Resolvers))
+	for _, entry := range config.Resolvers {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		resolver := &dnsResolver{name: entry}
+		switch {
+		case strings.HasPrefix(entry, "https://"):
+			if _, err := url.Parse(entry); err != nil {
+				return nil, fmt.Errorf("invalid DNS-over-HTTPS resolver %q: %w", entry, err)
+			}
+			resolver.dohURL = entry
+			resolver.client = &http.Client{Timeout: dnsQueryTimeout}
+		case net.ParseIP(entry) != nil:
+			resolver.address = net.JoinHostPort(entry, "53")
+		default:
+			host, port, err := net.SplitHostPort(entry)
+			if err != nil {
+				host, port = entry, "53"
+			}
+			if host == "" {
+				return nil, fmt.Errorf("invalid DNS resolver %q", entry)
+			}
+			resolver.address = net.JoinHostPort(host, port)
+		}
+		resolvers = append(resolvers, resolver)
+	}
+	return resolvers, nil
+}
+
+// lookupRecords queries every record type for the host in parallel
+func (r *dnsResolver) lookupRecords(ctx context.Context, hostname string) models.DNSResolverResult {
+	start := time.Now()
+	result := models.DNSResolverResult{Resolver: r.name, Records: []models.DNSRecord{}}
+
+	name, err := dnsmessage.NewName(dnsFQDN(hostname))
+	if err != nil {
+		result.Error = fmt.Sprintf("invalid host name: %v", err)
+		result.LookupTime = FormatDuration(time.Since(start))
+		return result
+	}
+
+	records := make([][]models.DNSRecord, len(dnsRecordTypes))
+	errs := make([]error, len(dnsRecordTypes))
+	var wg sync.WaitGroup
+	for i, qtype := range dnsRecordTypes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			records[i], errs[i] = r.query(ctx, name, qtype)
+		}()
+	}
+	wg.Wait()
+
+	var failures []string
+	for i, typeRecords := range records {
+		result.Records = append(result.Records, typeRecords...)
+		if errs[i] != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", dnsTypeName(dnsRecordTypes[i]), errs[i]))
+		}
+	}
+	if len(failures) == len(dnsRecordTypes) {
+		result.Error = errs[0].Error()
+	} else if len(failures) > 0 {
+		result.Error = strings.Join(failures, "; ")
+	}
+	result.LookupTime = FormatDuration(time.Since(start))
+	return result
+}
+
+// query sends one question and returns the answers of the asked type
+func (r *dnsResolver) query(ctx context.Context, name dnsmessage.Name, qtype dnsmessage.Type) ([]models.DNSRecord, error) {
+	id := uint16(rand.UintN(1 << 16))
+	if r.dohURL != "" {
+		id = 0 // RFC 8484 asks for a zero ID so answers can be cached
+	}
+
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(dnsUDPSize, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	query := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions:   []dnsmessage.Question{{Name: name, Type: qtype, Class: dnsmessage.ClassINET}},
+		Additionals: []dnsmessage.Resource{{Header: opt, Body: &dnsmessage.OPTResource{}}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	var answer []byte
+	if r.dohURL != "" {
+		answer, err = r.exchangeHTTPS(ctx, packed)
+	} else {
+		answer, err = r.exchangeUDP(ctx, packed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var response dnsmessage.Message
+	if err := response.Unpack(answer); err != nil {
+		return nil, fmt.Errorf("malformed answer: %w", err)
+	}
+	if response.Header.ID != id {
+		return nil, errors.New("answer does not match the query")
+	}
+	if response.Header.Truncated && r.dohURL == "" {
+		if answer, err = r.exchangeTCP(ctx, packed); err != nil {
+			return nil, err
+		}
+		if err := response.Unpack(answer); err != nil {
+			return nil, fmt.Errorf("malformed answer: %w", err)
+		}
+	}
+
+	switch response.Header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, errors.New("no such host (NXDOMAIN)")
+	default:
+		return nil, fmt.Errorf("resolver answered %s", strings.TrimPrefix(response.Header.RCode.String(), "RCode"))
+	}
+
+	var records []models.DNSRecord
+	for _, resource := range response.Answers {
+		if resource.Header.Type != qtype {
+			continue // e.g. the CNAME chain of an A query
+		}
+		if value := dnsRecordValue(resource.Body); value != "" {
+			ttl := resource.Header.TTL
+			records = append(records, models.DNSRecord{Type: dnsTypeName(qtype), Value: value, TTL: &ttl})
+		}
+	}
+	return records, nil
+}
+
+// exchangeUDP sends a query over UDP and returns the raw answer
+func (r *dnsResolver) exchangeUDP(ctx context.Context, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", r.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, dnsUDPSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+// exchangeTCP sends a query over TCP, used when the UDP answer was truncated
+func (r *dnsResolver) exchangeTCP(ctx context.Context, query []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", r.address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	framed := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(framed, query...)); err != nil {
+		return nil, err
+	}
+	var length [2]byte
+	if _, err := io.ReadFull(conn, length[:]); err != nil {
+		return nil, err
+	}
+	answer := make([]byte, binary.BigEndian.Uint16(length[:]))
+	if _, err := io.ReadFull(conn, answer); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// exchangeHTTPS posts a query to a DNS-over-HTTPS endpoint (RFC 8484)
+func (r *dnsResolver) exchangeHTTPS(ctx context.Context, query []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.dohURL, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS-over-HTTPS endpoint returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxDNSMessageSize))
+}
+
+// lookupSystemRecords queries the record types through the system resolver, which does not
+// report TTLs
+func lookupSystemRecords(ctx context.Context, hostname string, ips []net.IP) []models.DNSRecord {
+	var records []models.DNSRecord
+	for _, ip := range ips {
+		recordType := "AAAA"
+		if ip.To4() != nil {
+			recordType = "A"
+		}
+		records = append(records, models.DNSRecord{Type: recordType, Value: ip.String()})
+	}
+
+	resolver := net.DefaultResolver
+	if cname, err := resolver.LookupCNAME(ctx, hostname); err == nil && !strings.EqualFold(dnsFQDN(cname), dnsFQDN(hostname)) {
+		records = append(records, models.DNSRecord{Type: "CNAME", Value: cname})
+	}
+	if mxs, err := resolver.LookupMX(ctx, hostname); err == nil {
+		for _, mx := range mxs {
+			records = append(records, models.DNSRecord{Type: "MX", Value: fmt.Sprintf("%d %s", mx.Pref, mx.Host)})
+		}
+	}
+	if txts, err := resolver.LookupTXT(ctx, hostname); err == nil {
+		for _, txt := range txts {
+			records = append(records, models.DNSRecord{Type: "TXT", Value: txt})
+		}
+	}
+	if nss, err := resolver.LookupNS(ctx, hostname); err == nil {
+		for _, ns := range nss {
+			records = append(records, models.DNSRecord{Type: "NS", Value: ns.Host})
+		}
+	}
+	return records
+}
+
+// compareResolvers lists the record types whose values differ between resolvers; TTLs are
+// expected to differ and are ignored
+func compareResolvers(results []models.DNSResolverResult) []string {
+	var inconsistencies []string
+	for _, qtype := range dnsRecordTypes {
+		typeName := dnsTypeName(qtype)
+		answers := make([]string, 0, len(results))
+		differs, compared := false, false
+		var first []string
+		for _, result := range results {
+			if result.Error != "" && len(result.Records) == 0 {
+				continue // Unreachable resolvers are reported on their own
+			}
+			var values []string
+			for _, record := range result.Records {
+				if record.Type == typeName {
+					values = append(values, record.Value)
+				}
+			}
+			sort.Strings(values)
+			if !compared {
+				first, compared = values, true
+			} else if !slices.Equal(first, values) {
+				differs = true
+			}
+			if len(values) == 0 {
+				values = []string{"none"}
+			}
+			answers = append(answers, fmt.Sprintf("%s: %s", result.Resolver, strings.Join(values, ", ")))
+		}
+		if differs {
+			inconsistencies = append(inconsistencies, fmt.Sprintf("%s records differ (%s)", typeName, strings.Join(answers, "; ")))
+		}
+	}
+	return inconsistencies
+}
+
+// dnsRecordValue renders the data of a supported record type
+func dnsRecordValue(body dnsmessage.ResourceBody) string {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return b.CNAME.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", b.Pref, b.MX.String())
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, "")
+	case *dnsmessage.NSResource:
+		return b.NS.String()
+	default:
+		return ""
+	}
+}
+
+// dnsTypeName returns the record type without the package's "Type" prefix
+func dnsTypeName(qtype dnsmessage.Type) string {
+	return strings.TrimPrefix(qtype.String(), "Type")
+}
+
+// dnsFQDN returns the host name with its trailing dot
+func dnsFQDN(hostname string) string {
+	if strings.HasSuffix(hostname, ".") {
+		return hostname
+	}
+	return hostname + "."
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// acceptEncoding is advertised unless the request sets its own Accept-Encoding header
+const acceptEncoding = "gzip, deflate, br"
+
+// decodeBody reverses the Content-Encoding of a response body, applying the listed codings
+// in reverse order; the decoded body is limited to maxSize bytes
+func decodeBody(contentEncoding string, body []byte, maxSize int64) ([]byte, error) {
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		var reader io.Reader
+		switch coding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("invalid gzip body: %w", err)
+			}
+			reader = gzipReader
+		case "deflate":
+			// deflate is meant to be zlib-wrapped, but some servers send raw DEFLATE data
+			zlibReader, err := zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				reader = flate.NewReader(bytes.NewReader(body))
+			} else {
+				reader = zlibReader
+			}
+		case "br":
+			reader = brotli.NewReader(bytes.NewReader(body))
+		default:
+			return nil, fmt.Errorf("unsupported content encoding %q", coding)
+		}
+
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxSize))
+		// A body cut off by the response size limit still yields the data decoded so far
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("failed to decode %s body: %w", coding, err)
+		}
+		body = decoded
+	}
+	return body, nil
+}
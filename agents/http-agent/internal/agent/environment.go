@@ -0,0 +1,210 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrEnvironmentNotFound is returned when a named environment does not exist
+var ErrEnvironmentNotFound = errors.New("environment not found")
+
+// secretMask replaces secret values in API responses
+const secretMask = "********"
+
+// minMaskedSecretLength skips masking very short values that would corrupt unrelated text
+const minMaskedSecretLength = 4
+
+// EnvironmentStore keeps named environments in memory
+type EnvironmentStore struct {
+	mu           sync.RWMutex
+	environments map[string]*models.Environment
+}
+
+// NewEnvironmentStore creates a store seeded with the configured environments
+func NewEnvironmentStore(environments []models.Environment) *EnvironmentStore {
+	store := &EnvironmentStore{environments: make(map[string]*models.Environment)}
+	for i := range environments {
+		env := environments[i]
+		store.environments[env.Name] = &env
+	}
+	return store
+}
+
+// get returns the environment with its secret values
+func (s *EnvironmentStore) get(name string) (*models.Environment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	env, ok := s.environments[name]
+	if !ok {
+		return nil, ErrEnvironmentNotFound
+	}
+	return env, nil
+}
+
+// ListEnvironments returns all environments sorted by name, with secret values masked
+func (a *HTTPAgent) ListEnvironments() []models.Environment {
+	a.environments.mu.RLock()
+	defer a.environments.mu.RUnlock()
+
+	environments := make([]models.Environment, 0, len(a.environments.environments))
+	for _, env := range a.environments.environments {
+		environments = append(environments, maskedEnvironment(env))
+	}
+	sort.Slice(environments, func(i, j int) bool { return environments[i].Name < environments[j].Name })
+	return environments
+}
+
+// GetEnvironment returns a named environment with secret values masked
+func (a *HTTPAgent) GetEnvironment(name string) (*models.Environment, error) {
+	env, err := a.environments.get(name)
+	if err != nil {
+		return nil, err
+	}
+	masked := maskedEnvironment(env)
+	return &masked, nil
+}
+
+// SaveEnvironment creates or replaces an environment. Secret variables sent with an
+// empty value keep their current value, so masked environments can be edited and saved back.
+func (a *HTTPAgent) SaveEnvironment(env *models.Environment) (*models.Environment, error) {
+	if strings.TrimSpace(env.Name) == "" {
+		return nil, fmt.Errorf("environment name is required")
+	}
+
+	a.environments.mu.Lock()
+	defer a.environments.mu.Unlock()
+
+	saved := models.Environment{Name: env.Name, Variables: make([]models.EnvironmentVariable, 0, len(env.Variables))}
+	existing := a.environments.environments[env.Name]
+	for _, variable := range env.Variables {
+		if variable.Secret && (variable.Value == "" || variable.Value == secretMask) && existing != nil {
+			for _, current := range existing.Variables {
+				if current.Name == variable.Name {
+					variable.Value = current.Value
+				}
+			}
+		}
+		saved.Variables = append(saved.Variables, variable)
+	}
+	a.environments.environments[env.Name] = &saved
+
+	masked := maskedEnvironment(&saved)
+	return &masked, nil
+}
+
+// DeleteEnvironment removes a named environment
+func (a *HTTPAgent) DeleteEnvironment(name string) error {
+	a.environments.mu.Lock()
+	defer a.environments.mu.Unlock()
+
+	if _, ok := a.environments.environments[name]; !ok {
+		return ErrEnvironmentNotFound
+	}
+	delete(a.environments.environments, name)
+	return nil
+}
+
+// environmentVariables returns the variables of an environment and the secret values
+// to mask, keyed by value with the variable name as replacement placeholder
+func (a *HTTPAgent) environmentVariables(name string) (map[string]string, map[string]string, error) {
+	env, err := a.environments.get(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variables := make(map[string]string, len(env.Variables))
+	secrets := make(map[string]string)
+	for _, variable := range env.Variables {
+		variables[variable.Name] = variable.Value
+		if variable.Secret && len(variable.Value) >= minMaskedSecretLength {
+			secrets[variable.Value] = "{{" + variable.Name + "}}"
+		}
+	}
+	return variables, secrets, nil
+}
+
+// applyEnvironment substitutes the environment variables into the URL, headers and body
+// and returns the secret values that must be masked afterwards
+func (a *HTTPAgent) applyEnvironment(reqConfig *models.RequestConfig) (map[string]string, error) {
+	variables, secrets, err := a.environmentVariables(reqConfig.Environment)
+	if err != nil {
+		return nil, err
+	}
+
+	substituteRequest(reqConfig, variables)
+	return secrets, nil
+}
+
+// maskRequest redacts the auth block and replaces secret values in a request with their
+// {{name}} placeholders
+func maskRequest(reqConfig *models.RequestConfig, secrets map[string]string) {
+	reqConfig.Auth = redactAuth(reqConfig.Auth)
+	if len(secrets) == 0 {
+		return
+	}
+
+	reqConfig.URL = maskSecrets(reqConfig.URL, secrets)
+	reqConfig.Body = maskSecrets(reqConfig.Body, secrets)
+	headers := make(map[string]string, len(reqConfig.Headers))
+	for key, value := range reqConfig.Headers {
+		headers[key] = maskSecrets(value, secrets)
+	}
+	reqConfig.Headers = headers
+	if reqConfig.WebSocket != nil {
+		options := *reqConfig.WebSocket
+		options.Messages = make([]string, len(reqConfig.WebSocket.Messages))
+		for i, message := range reqConfig.WebSocket.Messages {
+			options.Messages[i] = maskSecrets(message, secrets)
+		}
+		reqConfig.WebSocket = &options
+	}
+	reqConfig.GraphQL = mapGraphQLStrings(reqConfig.GraphQL, func(text string) string {
+		return maskSecrets(text, secrets)
+	})
+}
+
+// maskResponse replaces secret values echoed back in a response
+func maskResponse(response *models.Response, secrets map[string]string) {
+	if response == nil || len(secrets) == 0 {
+		return
+	}
+
+	response.Body = maskSecrets(response.Body, secrets)
+	headers := make(http.Header, len(response.Headers))
+	for key, values := range response.Headers {
+		for _, value := range values {
+			headers[key] = append(headers[key], maskSecrets(value, secrets))
+		}
+	}
+	response.Headers = headers
+	for i := range response.Frames {
+		response.Frames[i].Data = maskSecrets(response.Frames[i].Data, secrets)
+	}
+}
+
+// maskSecrets replaces every secret value in the text with its placeholder
+func maskSecrets(text string, secrets map[string]string) string {
+	for value, placeholder := range secrets {
+		text = strings.ReplaceAll(text, value, placeholder)
+	}
+	return text
+}
+
+// maskedEnvironment copies an environment with secret values hidden
+func maskedEnvironment(env *models.Environment) models.Environment {
+	masked := models.Environment{Name: env.Name, Variables: make([]models.EnvironmentVariable, 0, len(env.Variables))}
+	for _, variable := range env.Variables {
+		if variable.Secret {
+			variable.Value = secretMask
+		}
+		masked.Variables = append(masked.Variables, variable)
+	}
+	return masked
+}
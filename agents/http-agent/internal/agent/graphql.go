@@ -0,0 +1,305 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// maxSchemaSummary limits the size of the introspected schema summary sent to the LLM
+const maxSchemaSummary = 4000
+
+// introspectionQuery fetches the type system needed to summarize a GraphQL schema
+const introspectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) { name args { name type { ...TypeRef } } type { ...TypeRef } }
+      inputFields { name type { ...TypeRef } }
+      enumValues(includeDeprecated: true) { name }
+    }
+  }
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } }
+}`
+
+// graphQLTypeRef is a (possibly wrapped) type reference from an introspection result
+type graphQLTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	OfType *graphQLTypeRef `json:"ofType"`
+}
+
+// graphQLField is a field or input value from an introspection result
+type graphQLField struct {
+	Name string         `json:"name"`
+	Args []graphQLField `json:"args"`
+	Type graphQLTypeRef `json:"type"`
+}
+
+// graphQLSchema is the subset of an introspection result used for the summary
+type graphQLSchema struct {
+	QueryType        *graphQLTypeRef `json:"queryType"`
+	MutationType     *graphQLTypeRef `json:"mutationType"`
+	SubscriptionType *graphQLTypeRef `json:"subscriptionType"`
+	Types            []struct {
+		Kind        string         `json:"kind"`
+		Name        string         `json:"name"`
+		Fields      []graphQLField `json:"fields"`
+		InputFields []graphQLField `json:"inputFields"`
+		EnumValues  []struct {
+			Name string `json:"name"`
+		} `json:"enumValues"`
+	} `json:"types"`
+}
+
+// graphQLResponse is the standard GraphQL response envelope
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+// prepareGraphQLRequest shapes a GraphQL operation into a JSON POST request
+func prepareGraphQLRequest(reqConfig *models.RequestConfig) error {
+	payload := map[string]interface{}{
+		"query": reqConfig.GraphQL.Query,
+	}
+	if len(reqConfig.GraphQL.Variables) > 0 {
+		payload["variables"] = reqConfig.GraphQL.Variables
+	}
+	if reqConfig.GraphQL.OperationName != "" {
+		payload["operationName"] = reqConfig.GraphQL.OperationName
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode GraphQL request: %w", err)
+	}
+
+	reqConfig.Method = "POST"
+	reqConfig.Body = string(body)
+	reqConfig.Headers = withDefaultHeader(reqConfig.Headers, "Content-Type", "application/json")
+	reqConfig.Headers = withDefaultHeader(reqConfig.Headers, "Accept", "application/json")
+
+	return nil
+}
+
+// mapGraphQLStrings applies fn to the query and every string among the variables, copying the
+// operation so the caller's copy is left untouched
+func mapGraphQLStrings(graphQL *models.GraphQLRequest, fn func(string) string) *models.GraphQLRequest {
+	if graphQL == nil {
+		return nil
+	}
+	mapped := *graphQL
+	mapped.Query = fn(graphQL.Query)
+	if graphQL.Variables != nil {
+		mapped.Variables = mapJSONStrings(graphQL.Variables, fn).(map[string]interface{})
+	}
+	return &mapped
+}
+
+// mapJSONStrings applies fn to every string in a decoded JSON value, copying objects and arrays
+func mapJSONStrings(value interface{}, fn func(string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case map[string]interface{}:
+		mapped := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			mapped[key] = mapJSONStrings(item, fn)
+		}
+		return mapped
+	case []interface{}:
+		mapped := make([]interface{}, len(v))
+		for i, item := range v {
+			mapped[i] = mapJSONStrings(item, fn)
+		}
+		return mapped
+	default:
+		return value
+	}
+}
+
+// introspectGraphQL runs an introspection query against the endpoint and returns a schema
+// summary; servers with introspection disabled yield an explanatory note instead
+func (a *HTTPAgent) introspectGraphQL(ctx context.Context, reqConfig *models.RequestConfig) string {
+	headers := make(map[string]string, len(reqConfig.Headers))
+	for key, value := range reqConfig.Headers {
+		headers[key] = value
+	}
+
+	introspection := models.RequestConfig{
+		URL:       reqConfig.URL,
+		Headers:   headers,
+		VerifySSL: reqConfig.VerifySSL,
+		Auth:      reqConfig.Auth,
+		GraphQL:   &models.GraphQLRequest{Query: introspectionQuery, OperationName: "IntrospectionQuery"},
+	}
+	if err := prepareGraphQLRequest(&introspection); err != nil {
+		return fmt.Sprintf("Introspection unavailable: %v", err)
+	}
+
+	response, err := a.httpClient.MakeRequest(ctx, &introspection)
+	if err != nil {
+		return fmt.Sprintf("Introspection unavailable: %v", err)
+	}
+
+	var result graphQLResponse
+	if err := json.Unmarshal([]byte(response.Body), &result); err != nil {
+		return fmt.Sprintf("Introspection unavailable: %s returned a non-GraphQL response", response.Status)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Sprintf("Introspection unavailable: %s", result.Errors[0].Message)
+	}
+
+	var data struct {
+		Schema graphQLSchema `json:"__schema"`
+	}
+	if err := json.Unmarshal(result.Data, &data); err != nil {
+		return fmt.Sprintf("Introspection unavailable: invalid schema: %v", err)
+	}
+
+	return summarizeGraphQLSchema(&data.Schema)
+}
+
+// summarizeGraphQLSchema renders the schema as compact SDL, skipping built-in types
+func summarizeGraphQLSchema(schema *graphQLSchema) string {
+	var sb strings.Builder
+
+	sb.WriteString("schema {")
+	if schema.QueryType != nil {
+		sb.WriteString(" query: " + schema.QueryType.Name)
+	}
+	if schema.MutationType != nil {
+		sb.WriteString(" mutation: " + schema.MutationType.Name)
+	}
+	if schema.SubscriptionType != nil {
+		sb.WriteString(" subscription: " + schema.SubscriptionType.Name)
+	}
+	sb.WriteString(" }\n")
+
+	types := schema.Types
+	sort.SliceStable(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || isBuiltinScalar(t.Name) {
+			continue
+		}
+
+		switch t.Kind {
+		case "OBJECT", "INTERFACE", "INPUT_OBJECT":
+			keyword := map[string]string{"OBJECT": "type", "INTERFACE": "interface", "INPUT_OBJECT": "input"}[t.Kind]
+			fields := t.Fields
+			if t.Kind == "INPUT_OBJECT" {
+				fields = t.InputFields
+			}
+			parts := make([]string, 0, len(fields))
+			for _, f := range fields {
+				parts = append(parts, formatGraphQLField(f))
+			}
+			sb.WriteString(fmt.Sprintf("%s %s { %s }\n", keyword, t.Name, strings.Join(parts, ", ")))
+		case "ENUM":
+			values := make([]string, 0, len(t.EnumValues))
+			for _, v := range t.EnumValues {
+				values = append(values, v.Name)
+			}
+			sb.WriteString(fmt.Sprintf("enum %s { %s }\n", t.Name, strings.Join(values, " ")))
+		default:
+			sb.WriteString(fmt.Sprintf("%s %s\n", strings.ToLower(t.Kind), t.Name))
+		}
+	}
+
+	summary := sb.String()
+	if len(summary) > maxSchemaSummary {
+		summary = summary[:maxSchemaSummary] + "... (truncated)"
+	}
+	return summary
+}
+
+// formatGraphQLField renders a field with its arguments and type
+func formatGraphQLField(field graphQLField) string {
+	if len(field.Args) == 0 {
+		return fmt.Sprintf("%s: %s", field.Name, formatGraphQLType(&field.Type))
+	}
+
+	args := make([]string, 0, len(field.Args))
+	for _, arg := range field.Args {
+		args = append(args, fmt.Sprintf("%s: %s", arg.Name, formatGraphQLType(&arg.Type)))
+	}
+	return fmt.Sprintf("%s(%s): %s", field.Name, strings.Join(args, ", "), formatGraphQLType(&field.Type))
+}
+
+// formatGraphQLType renders a type reference in SDL notation (e.g. [User!]!)
+func formatGraphQLType(ref *graphQLTypeRef) string {
+	if ref == nil {
+		return "?"
+	}
+	switch ref.Kind {
+	case "NON_NULL":
+		return formatGraphQLType(ref.OfType) + "!"
+	case "LIST":
+		return "[" + formatGraphQLType(ref.OfType) + "]"
+	default:
+		return ref.Name
+	}
+}
+
+// isBuiltinScalar reports whether the type is one of the GraphQL built-in scalars
+func isBuiltinScalar(name string) bool {
+	switch name {
+	case "String", "Int", "Float", "Boolean", "ID":
+		return true
+	}
+	return false
+}
+
+// graphQLErrors extracts the error messages from a GraphQL response body
+func graphQLErrors(body string) []string {
+	var result graphQLResponse
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		return nil
+	}
+
+	messages := make([]string, 0, len(result.Errors))
+	for _, e := range result.Errors {
+		if len(e.Path) > 0 {
+			path := make([]string, 0, len(e.Path))
+			for _, p := range e.Path {
+				path = append(path, fmt.Sprint(p))
+			}
+			messages = append(messages, fmt.Sprintf("%s (path: %s)", e.Message, strings.Join(path, ".")))
+			continue
+		}
+		messages = append(messages, e.Message)
+	}
+	return messages
+}
+
+// withDefaultHeader sets a header unless it is already present (case-insensitively)
+func withDefaultHeader(headers map[string]string, name, value string) map[string]string {
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+	for key := range headers {
+		if http.CanonicalHeaderKey(key) == http.CanonicalHeaderKey(name) {
+			return headers
+		}
+	}
+	headers[name] = value
+	return headers
+}
@@ -0,0 +1,345 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcContentType marks responses produced by gRPC calls
+const grpcContentType = "application/grpc"
+
+// isGRPCURL reports whether the URL uses the grpc or grpcs scheme
+func isGRPCURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return parsedURL.Scheme == "grpc" || parsedURL.Scheme == "grpcs"
+}
+
+// makeGRPCRequest calls a gRPC method resolved through server reflection. The URL path
+// selects what is done: empty lists the services, /pkg.Service lists its methods and
+// /pkg.Service/Method invokes the method with the JSON-encoded body as request message.
+func (c *HTTPClient) makeGRPCRequest(ctx context.Context, reqConfig *models.RequestConfig, verifySSL bool) (*models.Response, error) {
+	startTime := time.Now()
+
+	parsedURL, err := url.Parse(reqConfig.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	target := parsedURL.Host
+	creds := insecure.NewCredentials()
+	if parsedURL.Scheme == "grpcs" {
+		creds = credentials.NewTLS(c.tlsConfig(verifySSL))
+		if parsedURL.Port() == "" {
+			target = net.JoinHostPort(parsedURL.Hostname(), "443")
+		}
+	} else if parsedURL.Port() == "" {
+		target = net.JoinHostPort(parsedURL.Hostname(), "80")
+	}
+
+	conn, err := grpc.NewClient(target,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent("Intelligent-HTTP-Agent/1.0"),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return c.dialContext(ctx, "tcp", addr)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	// Request headers are sent as gRPC metadata
+	md := metadata.MD{}
+	for key, value := range reqConfig.Headers {
+		md.Set(key, value)
+	}
+	if id := telemetry.RequestID(ctx); id != "" && len(md.Get(telemetry.RequestIDHeader)) == 0 {
+		md.Set(telemetry.RequestIDHeader, id)
+	}
+	traceContext := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, traceContext)
+	for key, value := range traceContext {
+		md.Set(key, value)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	reflection, err := newReflectionResolver(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer reflection.close()
+
+	response := &models.Response{
+		Status:      "0 OK",
+		ContentType: grpcContentType,
+		Timestamp:   startTime,
+	}
+
+	service, method, _ := strings.Cut(strings.Trim(parsedURL.Path, "/"), "/")
+	switch {
+	case service == "":
+		services, err := reflection.listServices()
+		if err != nil {
+			return nil, err
+		}
+		response.Body = "Services:\n" + strings.Join(services, "\n")
+	case method == "":
+		sd, err := reflection.findService(service)
+		if err != nil {
+			return nil, err
+		}
+		response.Body = describeGRPCService(sd)
+	default:
+		sd, err := reflection.findService(service)
+		if err != nil {
+			return nil, err
+		}
+		if err := invokeGRPCMethod(ctx, conn, sd, method, reqConfig.Body, response); err != nil {
+			return nil, err
+		}
+	}
+
+	response.ContentLength = int64(len(response.Body))
+	response.Duration = time.Since(startTime)
+	return response, nil
+}
+
+// invokeGRPCMethod performs a unary call and records status, metadata and the decoded reply
+func invokeGRPCMethod(ctx context.Context, conn *grpc.ClientConn, sd protoreflect.ServiceDescriptor, method, body string, response *models.Response) error {
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return fmt.Errorf("method %s not found in service %s", method, sd.FullName())
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return fmt.Errorf("streaming method %s is not supported, only unary calls are", md.FullName())
+	}
+
+	in := dynamicpb.NewMessage(md.Input())
+	if strings.TrimSpace(body) != "" {
+		if err := protojson.Unmarshal([]byte(body), in); err != nil {
+			return fmt.Errorf("invalid request message for %s: %w", md.Input().FullName(), err)
+		}
+	}
+	out := dynamicpb.NewMessage(md.Output())
+
+	var header, trailer metadata.MD
+	err := conn.Invoke(ctx, fmt.Sprintf("/%s/%s", sd.FullName(), md.Name()), in, out,
+		grpc.Header(&header), grpc.Trailer(&trailer))
+
+	st := status.Convert(err)
+	response.StatusCode = int(st.Code())
+	response.Status = fmt.Sprintf("%d %s", st.Code(), st.Code())
+	response.Headers = header
+	response.Trailers = trailer
+
+	if err != nil {
+		response.Body = st.Message()
+		return nil
+	}
+
+	decoded, err := protojson.MarshalOptions{Multiline: true, EmitUnpopulated: true}.Marshal(out)
+	if err != nil {
+		return fmt.Errorf("failed to decode response message: %w", err)
+	}
+	response.Body = string(decoded)
+	return nil
+}
+
+// describeGRPCService lists the methods of a service with their message types
+func describeGRPCService(sd protoreflect.ServiceDescriptor) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("service %s {\n", sd.FullName()))
+	methods := sd.Methods()
+	for i := 0; i < methods.Len(); i++ {
+		md := methods.Get(i)
+		input, output := string(md.Input().FullName()), string(md.Output().FullName())
+		if md.IsStreamingClient() {
+			input = "stream " + input
+		}
+		if md.IsStreamingServer() {
+			output = "stream " + output
+		}
+		sb.WriteString(fmt.Sprintf("  rpc %s(%s) returns (%s)\n", md.Name(), input, output))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// reflectionResolver resolves service descriptors through the server reflection API.
+// v1alpha is used because every reflection-enabled server implementation supports it.
+type reflectionResolver struct {
+	stream reflectionpb.ServerReflection_ServerReflectionInfoClient
+	files  *protoregistry.Files
+	protos map[string]*descriptorpb.FileDescriptorProto
+}
+
+// newReflectionResolver opens a reflection stream on the connection
+func newReflectionResolver(ctx context.Context, conn *grpc.ClientConn) (*reflectionResolver, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("server reflection unavailable: %w", err)
+	}
+
+	return &reflectionResolver{
+		stream: stream,
+		files:  new(protoregistry.Files),
+		protos: make(map[string]*descriptorpb.FileDescriptorProto),
+	}, nil
+}
+
+// close ends the reflection stream
+func (r *reflectionResolver) close() {
+	r.stream.CloseSend()
+}
+
+// send performs a single reflection request/response exchange
+func (r *reflectionResolver) send(req *reflectionpb.ServerReflectionRequest) (*reflectionpb.ServerReflectionResponse, error) {
+	if err := r.stream.Send(req); err != nil {
+		return nil, fmt.Errorf("server reflection unavailable: %w", err)
+	}
+	resp, err := r.stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("server reflection unavailable: %w", err)
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, fmt.Errorf("server reflection error: %s", errResp.GetErrorMessage())
+	}
+	return resp, nil
+}
+
+// listServices returns the fully-qualified names of the services exposed by the server
+func (r *reflectionResolver) listServices() ([]string, error) {
+	resp, err := r.send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]string, 0, len(resp.GetListServicesResponse().GetService()))
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	return services, nil
+}
+
+// findService resolves a service descriptor by its fully-qualified name
+func (r *reflectionResolver) findService(name string) (protoreflect.ServiceDescriptor, error) {
+	resp, err := r.send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fileName, err := r.collect(resp)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.register(fileName); err != nil {
+		return nil, err
+	}
+
+	desc, err := r.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", name, err)
+	}
+	sd, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", name)
+	}
+	return sd, nil
+}
+
+// collect stores the file descriptors of a reflection response and returns the name of the first one
+func (r *reflectionResolver) collect(resp *reflectionpb.ServerReflectionResponse) (string, error) {
+	var first string
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fdp := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(raw, fdp); err != nil {
+			return "", fmt.Errorf("invalid file descriptor from server reflection: %w", err)
+		}
+		if first == "" {
+			first = fdp.GetName()
+		}
+		r.protos[fdp.GetName()] = fdp
+	}
+	if first == "" {
+		return "", fmt.Errorf("server reflection returned no file descriptors")
+	}
+	return first, nil
+}
+
+// register builds a file descriptor and its dependencies, fetching missing files by name
+func (r *reflectionResolver) register(fileName string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(fileName); err == nil {
+		return fd, nil
+	}
+
+	fdp, ok := r.protos[fileName]
+	if !ok {
+		// Well-known types are often omitted by servers; use the compiled-in copies
+		if fd, err := protoregistry.GlobalFiles.FindFileByPath(fileName); err == nil {
+			if err := r.files.RegisterFile(fd); err != nil {
+				return nil, err
+			}
+			return fd, nil
+		}
+
+		resp, err := r.send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: fileName},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.collect(resp); err != nil {
+			return nil, err
+		}
+		if fdp, ok = r.protos[fileName]; !ok {
+			return nil, fmt.Errorf("server reflection did not return %s", fileName)
+		}
+	}
+
+	for _, dep := range fdp.GetDependency() {
+		if _, err := r.register(dep); err != nil {
+			return nil, err
+		}
+	}
+
+	fd, err := protodesc.NewFile(fdp, r.files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build descriptor for %s: %w", fileName, err)
+	}
+	if err := r.files.RegisterFile(fd); err != nil {
+		return nil, fmt.Errorf("failed to register descriptor for %s: %w", fileName, err)
+	}
+	return fd, nil
+}
@@ -0,0 +1,227 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// maxHARReplayEntries limits how many HAR entries can be replayed in one import
+const maxHARReplayEntries = 20
+
+// ExportHAR builds a HAR document from the request history entries matching the filter
+func (a *HTTPAgent) ExportHAR(ctx context.Context, filter *models.HistoryFilter) (*models.HAR, error) {
+	entries, err := a.ListHistory(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	har := newHAR()
+	// History is listed newest first, HAR entries are chronological
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry, err := a.GetHistoryEntry(ctx, entries[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		har.Log.Entries = append(har.Log.Entries, harEntryFromResult(entry.CreatedAt, entry.Result))
+	}
+
+	return har, nil
+}
+
+// ImportHAR converts HAR entries into request configurations and, when requested,
+// replays the selected entries through the agent
+func (a *HTTPAgent) ImportHAR(ctx context.Context, importReq *models.HARImportRequest) ([]models.RequestConfig, []*models.AnalysisResult, error) {
+	requests := make([]models.RequestConfig, 0, len(importReq.HAR.Log.Entries))
+	for _, entry := range importReq.HAR.Log.Entries {
+		requests = append(requests, requestFromHAREntry(entry, importReq.Prompt))
+	}
+
+	if !importReq.Replay {
+		return requests, nil, nil
+	}
+
+	selected := importReq.Entries
+	if len(selected) == 0 {
+		for i := range requests {
+			selected = append(selected, i)
+		}
+	}
+	if len(selected) > maxHARReplayEntries {
+		return nil, nil, fmt.Errorf("cannot replay more than %d entries at once", maxHARReplayEntries)
+	}
+
+	results := make([]*models.AnalysisResult, 0, len(selected))
+	for _, idx := range selected {
+		if idx < 0 || idx >= len(requests) {
+			return nil, nil, fmt.Errorf("entry index %d out of range", idx)
+		}
+
+		reqConfig := requests[idx]
+		result, err := a.Execute(ctx, &reqConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, result)
+	}
+
+	return requests, results, nil
+}
+
+// newHAR creates an empty HAR document
+func newHAR() *models.HAR {
+	return &models.HAR{
+		Log: models.HARLog{
+			Version: "1.2",
+			Creator: models.HARCreator{Name: "Intelligent-HTTP-Agent", Version: "1.0"},
+			Entries: []models.HAREntry{},
+		},
+	}
+}
+
+// harEntryFromResult converts an executed request into a HAR entry
+func harEntryFromResult(startedAt time.Time, result *models.AnalysisResult) models.HAREntry {
+	request := result.Request
+	entry := models.HAREntry{
+		StartedDateTime: startedAt.Format(time.RFC3339Nano),
+		Request: models.HARRequest{
+			Method:      request.Method,
+			URL:         request.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []models.HARNameValue{},
+			Headers:     sortedHeaderPairs(request.Headers),
+			QueryString: queryStringPairs(request.URL),
+			HeadersSize: -1,
+			BodySize:    len(request.Body),
+		},
+		Timings: models.HARTimings{Send: -1, Wait: -1, Receive: -1},
+		Comment: result.Error,
+	}
+
+	if request.Body != "" {
+		entry.Request.PostData = &models.HARPostData{
+			MimeType: request.Headers["Content-Type"],
+			Text:     request.Body,
+		}
+	}
+
+	response := result.Response
+	if response == nil {
+		// Failed requests are exported with status 0, as browsers do
+		entry.Response = models.HARResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []models.HARNameValue{},
+			Headers:     []models.HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		return entry
+	}
+
+	headers := []models.HARNameValue{}
+	names := make([]string, 0, len(response.Headers))
+	for name := range response.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range response.Headers[name] {
+			headers = append(headers, models.HARNameValue{Name: name, Value: value})
+		}
+	}
+
+	// The body is stored decoded; bodySize is what was received on the wire
+	bodySize := len(response.Body)
+	if response.CompressedSize > 0 {
+		bodySize = int(response.CompressedSize)
+	}
+
+	ms := float64(response.Duration) / float64(time.Millisecond)
+	entry.Time = ms
+	entry.Timings.Wait = ms
+	entry.Response = models.HARResponse{
+		Status:      response.StatusCode,
+		StatusText:  strings.TrimPrefix(response.Status, strconv.Itoa(response.StatusCode)+" "),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []models.HARNameValue{},
+		Headers:     headers,
+		Content: models.HARContent{
+			Size:        len(response.Body),
+			Compression: len(response.Body) - bodySize,
+			MimeType:    response.ContentType,
+			Text:        response.Body,
+		},
+		RedirectURL: http.Header(response.Headers).Get("Location"),
+		HeadersSize: -1,
+		BodySize:    bodySize,
+	}
+
+	return entry
+}
+
+// requestFromHAREntry converts a HAR entry into a request configuration
+func requestFromHAREntry(entry models.HAREntry, prompt string) models.RequestConfig {
+	headers := make(map[string]string)
+	for _, h := range entry.Request.Headers {
+		name := h.Name
+		// Skip HTTP/2 pseudo-headers and headers computed by the client
+		if strings.HasPrefix(name, ":") || strings.EqualFold(name, "Content-Length") || strings.EqualFold(name, "Host") {
+			continue
+		}
+		headers[name] = h.Value
+	}
+
+	reqConfig := models.RequestConfig{
+		URL:     entry.Request.URL,
+		Method:  strings.ToUpper(entry.Request.Method),
+		Headers: headers,
+		Prompt:  prompt,
+	}
+	if reqConfig.Method == "" {
+		reqConfig.Method = "GET"
+	}
+	if entry.Request.PostData != nil {
+		reqConfig.Body = entry.Request.PostData.Text
+	}
+
+	return reqConfig
+}
+
+// sortedHeaderPairs converts a header map into name/value pairs sorted by name
+func sortedHeaderPairs(headers map[string]string) []models.HARNameValue {
+	pairs := make([]models.HARNameValue, 0, len(headers))
+	for name, value := range headers {
+		pairs = append(pairs, models.HARNameValue{Name: name, Value: value})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// queryStringPairs extracts query parameters from a URL
+func queryStringPairs(rawURL string) []models.HARNameValue {
+	pairs := []models.HARNameValue{}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+
+	query := parsedURL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, models.HARNameValue{Name: key, Value: value})
+		}
+	}
+	return pairs
+}
@@ -4,68 +4,50 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/audit"
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+// defaultMaxRedirects limits redirect chains when max_redirects is not set
+const defaultMaxRedirects = 10
+
 // HTTPClient handles HTTP request execution
 type HTTPClient struct {
-	client          *http.Client
 	config          *models.HTTPConfig
 	maxResponseSize int64
 	blockPrivateIPs bool
+	rootCAs         *x509.CertPool // nil uses the system trust store
+	cookieJars      *CookieJarStore
+	policy          *targetPolicy
+	audit           *audit.Log          // nil when the audit log is disabled
+	limiter         *concurrencyLimiter // nil when concurrent requests are not capped
+	tokens          *tokenCache         // OAuth2 tokens of request auth blocks
 }
 
 // NewHTTPClient creates a new HTTP client with the given configuration
-func NewHTTPClient(config *models.HTTPConfig) *HTTPClient {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: !config.VerifySSL,
-		},
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			dialer := &net.Dialer{
-				Timeout:   time.Duration(config.Timeout) * time.Second,
-				KeepAlive: 30 * time.Second,
-			}
-
-			// Block private IPs if configured
-			if config.BlockPrivateIPs {
-				host, _, err := net.SplitHostPort(addr)
-				if err != nil {
-					host = addr
-				}
-
-				if isPrivateIP(host) {
-					return nil, fmt.Errorf("access to private IP addresses is blocked")
-				}
-			}
-
-			return dialer.DialContext(ctx, network, addr)
-		},
-	}
-
-	client := &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(config.Timeout) * time.Second,
+func NewHTTPClient(config *models.HTTPConfig) (*HTTPClient, error) {
+	rootCAs, err := loadRootCAs(config.CABundle)
+	if err != nil {
+		return nil, err
 	}
 
-	if !config.FollowRedirects {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		}
-	} else if config.MaxRedirects > 0 {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			if len(via) >= config.MaxRedirects {
-				return fmt.Errorf("stopped after %d redirects", config.MaxRedirects)
-			}
-			return nil
-		}
+	policy, err := newTargetPolicy(&config.Policy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target policy: %w", err)
 	}
 
 	maxSize := int64(10 * 1024 * 1024) // 10MB default
@@ -74,11 +56,14 @@ func NewHTTPClient(config *models.HTTPConfig) *HTTPClient {
 	}
 
 	return &HTTPClient{
-		client:          client,
 		config:          config,
 		maxResponseSize: maxSize,
 		blockPrivateIPs: config.BlockPrivateIPs,
-	}
+		rootCAs:         rootCAs,
+		cookieJars:      NewCookieJarStore(),
+		policy:          policy,
+		tokens:          newTokenCache(),
+	}, nil
 }
 
 // MakeRequest executes an HTTP request and returns the response
@@ -87,8 +72,17 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, reqConfig *models.RequestC
 
 	// Validate URL
 	if err := c.validateURL(reqConfig.URL); err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
+		err = fmt.Errorf("invalid URL: %w", err)
+		c.auditRequest(ctx, "http", reqConfig, nil, err, startTime)
+		return nil, err
+	}
+
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		c.auditRequest(ctx, "http", reqConfig, nil, err, startTime)
+		return nil, err
 	}
+	defer release()
 
 	// Determine SSL verification setting (per-request overrides global)
 	verifySSL := c.config.VerifySSL
@@ -96,22 +90,58 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, reqConfig *models.RequestC
 		verifySSL = *reqConfig.VerifySSL
 	}
 
+	// Credentials only go into the request that is sent, never into the caller's copy
+	sent, credentials, err := c.authenticate(ctx, reqConfig)
+	if err != nil {
+		c.auditRequest(ctx, "http", reqConfig, nil, err, startTime)
+		return nil, err
+	}
+
+	// Targets may echo the credentials back, e.g. debugging endpoints or error pages
+	response, err := c.send(ctx, sent, verifySSL, startTime)
+	maskResponse(response, credentials)
+	if err != nil {
+		if masked := maskSecrets(err.Error(), credentials); masked != err.Error() {
+			err = errors.New(masked)
+		}
+	}
+
+	// HTTP round trips are audited by the transport; other protocols once per call, masked
+	switch {
+	case isWebSocketURL(reqConfig.URL):
+		c.auditRequest(ctx, "websocket", reqConfig, response, err, startTime)
+	case isGRPCURL(reqConfig.URL):
+		c.auditRequest(ctx, "grpc", reqConfig, response, err, startTime)
+	}
+	return response, err
+}
+
+// send executes the request with its credentials applied
+func (c *HTTPClient) send(ctx context.Context, sent *models.RequestConfig, verifySSL bool, startTime time.Time) (*models.Response, error) {
+	if isWebSocketURL(sent.URL) {
+		return c.makeWebSocketRequest(ctx, sent, verifySSL)
+	}
+	if isGRPCURL(sent.URL) {
+		return c.makeGRPCRequest(ctx, sent, verifySSL)
+	}
+
 	// Create a custom client for this request with the specified SSL verification
 	client := c.createCustomClient(verifySSL)
+	client.Jar = c.cookieJar(sent.CookieJar)
 
 	// Create request
 	var bodyReader io.Reader
-	if reqConfig.Body != "" {
-		bodyReader = bytes.NewBufferString(reqConfig.Body)
+	if sent.Body != "" {
+		bodyReader = bytes.NewBufferString(sent.Body)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, reqConfig.Method, reqConfig.URL, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, sent.Method, sent.URL, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add headers
-	for key, value := range reqConfig.Headers {
+	for key, value := range sent.Headers {
 		req.Header.Set(key, value)
 	}
 
@@ -120,6 +150,11 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, reqConfig *models.RequestC
 		req.Header.Set("User-Agent", "Intelligent-HTTP-Agent/1.0")
 	}
 
+	// Decode bodies ourselves so the compressed size can be reported
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
 	// Execute request
 	resp, err := client.Do(req)
 	if err != nil {
@@ -138,15 +173,27 @@ func (c *HTTPClient) MakeRequest(ctx context.Context, reqConfig *models.RequestC
 
 	// Build response
 	response := &models.Response{
-		StatusCode:    resp.StatusCode,
-		Status:        resp.Status,
-		Headers:       resp.Header,
-		Body:          string(bodyBytes),
-		Duration:      duration,
-		ContentType:   resp.Header.Get("Content-Type"),
-		ContentLength: resp.ContentLength,
-		Timestamp:     startTime,
+		StatusCode:      resp.StatusCode,
+		Status:          resp.Status,
+		Headers:         resp.Header,
+		Duration:        duration,
+		ContentType:     resp.Header.Get("Content-Type"),
+		ContentLength:   resp.ContentLength,
+		Timestamp:       startTime,
+		ContentEncoding: resp.Header.Get("Content-Encoding"),
+		CompressedSize:  int64(len(bodyBytes)),
+	}
+
+	// Undecodable bodies are kept as received
+	decoded, err := decodeBody(response.ContentEncoding, bodyBytes, c.maxResponseSize)
+	if err == nil {
+		bodyBytes = decoded
+		response.DecompressedSize = int64(len(decoded))
+		if response.CompressedSize > 0 {
+			response.CompressionRatio = float64(response.DecompressedSize) / float64(response.CompressedSize)
+		}
 	}
+	response.Body = string(bodyBytes)
 
 	return response, nil
 }
@@ -158,9 +205,15 @@ func (c *HTTPClient) validateURL(rawURL string) error {
 		return fmt.Errorf("malformed URL: %w", err)
 	}
 
-	// Ensure scheme is http or https
-	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
-		return fmt.Errorf("only http and https schemes are allowed")
+	// Ensure scheme is http(s), ws(s) or grpc(s)
+	switch parsedURL.Scheme {
+	case "http", "https", "ws", "wss", "grpc", "grpcs":
+	default:
+		return fmt.Errorf("only http, https, ws, wss, grpc and grpcs schemes are allowed")
+	}
+
+	if err := c.policy.checkURL(parsedURL); err != nil {
+		return err
 	}
 
 	// Block private IPs if configured
@@ -174,75 +227,112 @@ func (c *HTTPClient) validateURL(rawURL string) error {
 	return nil
 }
 
-// isPrivateIP checks if the given host is a private IP address
+// blockedNetworks are the ranges refused when private IPs are blocked, besides loopback,
+// private, link-local, multicast and unspecified addresses
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",      // "This" network
+	"100.64.0.0/10",  // Carrier-grade NAT
+	"192.0.0.0/24",   // IETF protocol assignments
+	"198.18.0.0/15",  // Benchmarking
+	"240.0.0.0/4",    // Reserved, including broadcast
+	"64:ff9b::/96",   // NAT64, which embeds IPv4 addresses
+	"64:ff9b:1::/48", // Local-use NAT64
+	"2002::/16",      // 6to4, which embeds IPv4 addresses
+)
+
+// isPrivateIP reports whether a host is, or resolves to, an address that must not be reached
+// when private IPs are blocked. Every resolved address is checked, not just the first one
 func isPrivateIP(host string) bool {
-	// Check for localhost
-	if host == "localhost" || host == "127.0.0.1" || host == "::1" {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
 		return true
 	}
 
-	ip := net.ParseIP(host)
-	if ip == nil {
-		// Try to resolve hostname
-		ips, err := net.LookupIP(host)
-		if err != nil || len(ips) == 0 {
-			return false
-		}
-		ip = ips[0]
+	if ip := net.ParseIP(host); ip != nil {
+		return isBlockedIP(ip)
 	}
 
-	// Check for private IP ranges
-	privateRanges := []string{
-		"10.0.0.0/8",
-		"172.16.0.0/12",
-		"192.168.0.0/16",
-		"169.254.0.0/16",
-		"127.0.0.0/8",
-		"fc00::/7",
-		"fe80::/10",
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return false // The dialer refuses hosts it cannot resolve
 	}
-
-	for _, cidr := range privateRanges {
-		_, subnet, err := net.ParseCIDR(cidr)
-		if err != nil {
-			continue
-		}
-		if subnet.Contains(ip) {
+	for _, ip := range ips {
+		if isBlockedIP(ip) {
 			return true
 		}
 	}
+	return false
+}
 
+// isBlockedIP reports whether an address is internal: loopback, private, link-local,
+// multicast, unspecified or one of the blocked networks. IPv4-mapped IPv6 addresses are
+// checked as IPv4
+func isBlockedIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
 	return false
 }
 
-// createCustomClient creates an HTTP client with custom SSL verification settings
-func (c *HTTPClient) createCustomClient(verifySSL bool) *http.Client {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: !verifySSL,
-		},
-		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-			dialer := &net.Dialer{
-				Timeout:   time.Duration(c.config.Timeout) * time.Second,
-				KeepAlive: 30 * time.Second,
-			}
+// mustParseCIDRs parses a list of known-valid CIDR ranges
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
 
-			// Block private IPs if configured
-			if c.blockPrivateIPs {
-				host, _, err := net.SplitHostPort(addr)
-				if err != nil {
-					host = addr
-				}
+// createCustomClient creates an HTTP client with custom SSL verification settings. Requests are
+// traced, logged, audited and carry the trace context and request ID to the target
+func (c *HTTPClient) createCustomClient(verifySSL bool) *http.Client {
+	var transport http.RoundTripper = c.newTransport(verifySSL)
+	if c.audit != nil {
+		transport = &audit.Transport{Base: transport, Log: c.audit}
+	}
+	return c.newClient(otelhttp.NewTransport(&telemetry.Transport{Base: transport, Component: "http"}))
+}
 
-				if isPrivateIP(host) {
-					return nil, fmt.Errorf("access to private IP addresses is blocked")
-				}
-			}
+// auditRequest records a request that does not go through an audited transport
+func (c *HTTPClient) auditRequest(ctx context.Context, protocol string, reqConfig *models.RequestConfig, response *models.Response, err error, start time.Time) {
+	entry := models.AuditEntry{
+		Timestamp: start.UTC(),
+		Protocol:  protocol,
+		Method:    strings.ToUpper(reqConfig.Method),
+		URL:       reqConfig.URL,
+		Duration:  time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else if response != nil {
+		entry.Status = response.StatusCode
+	}
+	c.audit.Record(ctx, entry)
+}
 
-			return dialer.DialContext(ctx, network, addr)
-		},
+// newTransport creates a transport with custom SSL verification settings and private IP blocking
+func (c *HTTPClient) newTransport(verifySSL bool) *http.Transport {
+	return &http.Transport{
+		TLSClientConfig: c.tlsConfig(verifySSL),
+		DialContext:     c.dialContext,
 	}
+}
 
+// newClient creates an HTTP client applying the configured timeout and redirect policy
+func (c *HTTPClient) newClient(transport http.RoundTripper) *http.Client {
 	client := &http.Client{
 		Transport: transport,
 		Timeout:   time.Duration(c.config.Timeout) * time.Second,
@@ -252,18 +342,132 @@ func (c *HTTPClient) createCustomClient(verifySSL bool) *http.Client {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
-	} else if c.config.MaxRedirects > 0 {
-		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			if len(via) >= c.config.MaxRedirects {
-				return fmt.Errorf("stopped after %d redirects", c.config.MaxRedirects)
-			}
-			return nil
+		return client
+	}
+
+	maxRedirects := c.config.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
 		}
+		// Every hop gets the same scheme and private IP checks as the original URL
+		if err := c.validateURL(req.URL.String()); err != nil {
+			return fmt.Errorf("redirect to %s refused: %w", req.URL.Redacted(), err)
+		}
+		return nil
 	}
 
 	return client
 }
 
+// tlsConfig returns the TLS settings for a request, trusting the configured CA bundle
+func (c *HTTPClient) tlsConfig(verifySSL bool) *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: !verifySSL,
+		RootCAs:            c.rootCAs,
+	}
+}
+
+// loadRootCAs builds a pool of the system roots plus the certificates in a PEM file
+// or in every .pem, .crt and .cer file of a directory. An empty path returns nil.
+func loadRootCAs(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle directory: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			switch strings.ToLower(filepath.Ext(entry.Name())) {
+			case ".pem", ".crt", ".cer":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+
+	added := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate %s: %w", file, err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", file)
+		}
+		added++
+	}
+	if added == 0 {
+		return nil, fmt.Errorf("no CA certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// dialContext opens a connection, refusing private addresses and denied ranges when configured.
+// The host is resolved once and every address is checked before dialing one of the checked
+// addresses, so a DNS answer that changes between the check and the connection (DNS rebinding)
+// cannot reach an internal address
+func (c *HTTPClient) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(c.config.Timeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	if !c.blockPrivateIPs && len(c.policy.deniedNetworks) == 0 {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	resolved, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range resolved {
+		if c.blockPrivateIPs && isBlockedIP(address.IP) {
+			return nil, fmt.Errorf("access to private IP addresses is blocked (%s resolves to %s)", host, address.IP)
+		}
+		if err := c.policy.checkIP(host, address.IP); err != nil {
+			return nil, err
+		}
+	}
+
+	var lastErr error
+	for _, address := range resolved {
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(address.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, lastErr
+}
+
 // FormatDuration returns a human-readable duration string
 func FormatDuration(d time.Duration) string {
 	if d < time.Millisecond {
@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+const (
+	// defaultInvestigationSteps is used when no step budget is configured
+	defaultInvestigationSteps = 5
+
+	// maxToolResultBody limits how much of a follow-up response body is sent back to the LLM
+	maxToolResultBody = 2000
+)
+
+// investigationAction is a tool call requested by the LLM during an investigation
+type investigationAction struct {
+	Tool      string               `json:"tool"`
+	Reason    string               `json:"reason"`
+	Arguments models.RequestConfig `json:"arguments"`
+}
+
+// investigate lets the LLM issue follow-up requests to the same host until it reaches
+// a diagnosis or exhausts the step budget; auth is the original request's unredacted auth block
+func (a *HTTPAgent) investigate(ctx context.Context, reqConfig *models.RequestConfig, auth *models.RequestAuth, response *models.Response) (string, []models.InvestigationStep, error) {
+	systemPrompt, err := a.prompts.System(reqConfig.Profile)
+	if err != nil {
+		return "", nil, err
+	}
+	systemPrompt = buildInvestigationPrompt(systemPrompt, a.maxSteps)
+	messages, err := a.prompts.AnalysisMessages(reqConfig.Profile, reqConfig, response, reqConfig.Prompt)
+	if err != nil {
+		return "", nil, err
+	}
+	steps := []models.InvestigationStep{}
+
+	for {
+		reply, err := a.llmClient.Chat(ctx, systemPrompt, messages)
+		if err != nil {
+			return "", steps, err
+		}
+
+		action, ok := parseInvestigationAction(reply)
+		if !ok {
+			return reply, steps, nil
+		}
+		messages = append(messages, models.ChatMessage{Role: "assistant", Content: reply})
+
+		if len(steps) >= a.maxSteps {
+			messages = append(messages, models.ChatMessage{
+				Role:    "user",
+				Content: "The request budget is exhausted. Give your final diagnosis now based on the results so far.",
+			})
+			diagnosis, err := a.llmClient.Chat(ctx, systemPrompt, messages)
+			if err != nil {
+				return "", steps, err
+			}
+			return diagnosis, steps, nil
+		}
+
+		step, result := a.runInvestigationStep(ctx, reqConfig, auth, action)
+		steps = append(steps, step)
+		messages = append(messages, models.ChatMessage{Role: "user", Content: result})
+	}
+}
+
+// runInvestigationStep executes a follow-up request and describes the outcome for the LLM.
+// Follow-ups to the original origin are sent with the original credentials
+func (a *HTTPAgent) runInvestigationStep(ctx context.Context, original *models.RequestConfig, auth *models.RequestAuth, action *investigationAction) (models.InvestigationStep, string) {
+	followUp := action.Arguments
+	followUp.Method = strings.ToUpper(strings.TrimSpace(followUp.Method))
+	if followUp.Method == "" {
+		followUp.Method = "GET"
+	}
+	followUp.VerifySSL = original.VerifySSL
+	followUp.Prompt = ""
+	followUp.OpenAPI = nil
+	followUp.Investigate = false
+	followUp.Environment = ""
+	followUp.CookieJar = original.CookieJar
+	followUp.Auth = nil
+
+	step := models.InvestigationStep{Reason: action.Reason, Request: &followUp}
+
+	// The LLM only sees masked secrets, so their placeholders are substituted again here
+	baseURL := original.URL
+	var secrets map[string]string
+	if original.Environment != "" {
+		variables, envSecrets, err := a.environmentVariables(original.Environment)
+		if err != nil {
+			step.Error = err.Error()
+			return step, fmt.Sprintf("Request rejected: %v", err)
+		}
+		secrets = envSecrets
+		baseURL = substituteVariables(baseURL, variables)
+		substituteRequest(&followUp, variables)
+	}
+
+	target, err := resolveInvestigationURL(baseURL, followUp.URL)
+	if err != nil {
+		maskRequest(&followUp, secrets)
+		step.Error = maskSecrets(err.Error(), secrets)
+		return step, fmt.Sprintf("Request rejected: %s", step.Error)
+	}
+	followUp.URL = target
+	if sameOrigin(baseURL, target) {
+		followUp.Auth = auth
+	}
+
+	response, err := a.httpClient.MakeRequest(ctx, &followUp)
+	maskRequest(&followUp, secrets)
+	maskResponse(response, secrets)
+	if err != nil {
+		step.Error = maskSecrets(err.Error(), secrets)
+		return step, fmt.Sprintf("Result of %s %s:\nRequest failed: %s", followUp.Method, followUp.URL, step.Error)
+	}
+
+	step.StatusCode = response.StatusCode
+	step.Status = response.Status
+	step.Duration = FormatDuration(response.Duration)
+
+	return step, describeToolResult(&followUp, response)
+}
+
+// resolveInvestigationURL resolves a follow-up URL against the original request
+// and keeps the investigation on the original host
+func resolveInvestigationURL(originalURL, target string) (string, error) {
+	base, err := url.Parse(originalURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid original URL: %w", err)
+	}
+
+	ref, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", target, err)
+	}
+
+	resolved := base.ResolveReference(ref)
+	if !strings.EqualFold(resolved.Hostname(), base.Hostname()) {
+		return "", fmt.Errorf("follow-up requests are limited to host %s", base.Hostname())
+	}
+
+	return resolved.String(), nil
+}
+
+// sameOrigin reports whether two URLs share scheme, host and port
+func sameOrigin(a, b string) bool {
+	first, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	second, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(first.Scheme, second.Scheme) && strings.EqualFold(first.Hostname(), second.Hostname()) &&
+		targetPort(first) == targetPort(second)
+}
+
+// parseInvestigationAction extracts a tool call from an LLM reply; any other reply is the final diagnosis
+func parseInvestigationAction(reply string) (*investigationAction, bool) {
+	var action investigationAction
+	if err := json.Unmarshal([]byte(extractJSONObject(reply)), &action); err != nil {
+		return nil, false
+	}
+	if action.Tool != "http_request" {
+		return nil, false
+	}
+	return &action, true
+}
+
+// describeToolResult formats a follow-up response as a tool result message
+func describeToolResult(request *models.RequestConfig, response *models.Response) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Result of %s %s:\n", request.Method, request.URL))
+	sb.WriteString(fmt.Sprintf("- Status: %s\n", response.Status))
+	sb.WriteString(fmt.Sprintf("- Duration: %s\n", FormatDuration(response.Duration)))
+
+	names := make([]string, 0, len(response.Headers))
+	for name := range response.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	sb.WriteString("- Headers:\n")
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", name, strings.Join(redactCookieHeaders(response.Headers)[name], ", ")))
+	}
+
+	if response.Body != "" {
+		body := response.Body
+		if len(body) > maxToolResultBody {
+			body = body[:maxToolResultBody] + "... (truncated)"
+		}
+		sb.WriteString(fmt.Sprintf("- Body:\n%s\n", body))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+func TestRunInvestigationStepAuth(t *testing.T) {
+	protected := func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	api := httptest.NewServer(http.HandlerFunc(protected))
+	defer api.Close()
+	other := httptest.NewServer(http.HandlerFunc(protected))
+	defer other.Close()
+
+	httpClient, err := NewHTTPClient(&models.HTTPConfig{Timeout: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &HTTPAgent{httpClient: httpClient}
+
+	auth := &models.RequestAuth{Type: "bearer", Token: "s3cret"}
+	original := &models.RequestConfig{
+		Method: "GET",
+		URL:    api.URL + "/orders",
+		Auth:   redactAuth(auth), // Execute masks the original before investigating
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		auth       *models.RequestAuth // Supplied by the LLM
+		wantStatus int
+		wantToken  string
+	}{
+		{name: "same origin", url: "/orders/42", wantStatus: http.StatusOK, wantToken: secretMask},
+		{name: "masked auth from the LLM", url: "/orders/42", auth: redactAuth(auth), wantStatus: http.StatusOK, wantToken: secretMask},
+		{name: "other origin", url: other.URL + "/orders/42", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := &investigationAction{
+				Tool:      "http_request",
+				Arguments: models.RequestConfig{Method: "get", URL: tt.url, Auth: tt.auth},
+			}
+
+			step, _ := a.runInvestigationStep(context.Background(), original, auth, action)
+			if step.Error != "" {
+				t.Fatalf("step failed: %s", step.Error)
+			}
+			if step.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", step.StatusCode, tt.wantStatus)
+			}
+
+			var token string
+			if step.Request.Auth != nil {
+				token = step.Request.Auth.Token
+			}
+			if token != tt.wantToken {
+				t.Errorf("recorded token = %q, want %q", token, tt.wantToken)
+			}
+		})
+	}
+
+	if auth.Token != "s3cret" {
+		t.Errorf("original credentials were modified: %q", auth.Token)
+	}
+}
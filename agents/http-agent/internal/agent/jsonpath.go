@@ -0,0 +1,217 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is a single step of a JSONPath expression
+type pathSegment struct {
+	key       string // Object key or array index
+	wildcard  bool   // [*], .* or jq-style []: every element or member
+	recursive bool   // ..key: the key at any depth
+}
+
+// evaluateJSONPath resolves a JSONPath ($.a.b[0]['c d']) or jq-style (.a.b[0]) expression against a
+// JSON document; expressions with wildcards or recursive descent return an array of the matches
+func evaluateJSONPath(body, path string) (interface{}, error) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, fmt.Errorf("response body is not JSON: %w", err)
+	}
+
+	values, multiple, err := queryJSONPath(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if !multiple {
+		return values[0], nil
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s: no matches", path)
+	}
+	return values, nil
+}
+
+// queryJSONPath returns every value matched by an expression and whether the expression
+// can match more than one value; single-value expressions fail on missing keys
+func queryJSONPath(doc interface{}, path string) ([]interface{}, bool, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := []interface{}{doc}
+	multiple := false
+	for _, segment := range segments {
+		var next []interface{}
+		switch {
+		case segment.wildcard:
+			multiple = true
+			for _, node := range current {
+				next = append(next, children(node)...)
+			}
+		case segment.recursive:
+			multiple = true
+			for _, node := range current {
+				next = append(next, descendantValues(node, segment.key)...)
+			}
+		default:
+			for _, node := range current {
+				value, err := childValue(node, segment.key, path)
+				if err != nil {
+					if multiple {
+						continue
+					}
+					return nil, false, err
+				}
+				next = append(next, value)
+			}
+		}
+		current = next
+	}
+
+	return current, multiple, nil
+}
+
+// childValue returns the member or array element named by a key
+func childValue(node interface{}, key, path string) (interface{}, error) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		value, ok := node[key]
+		if !ok {
+			return nil, fmt.Errorf("%s: key %q not found", path, key)
+		}
+		return value, nil
+	case []interface{}:
+		index, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %q is not an array index", path, key)
+		}
+		if index < 0 {
+			index += len(node)
+		}
+		if index < 0 || index >= len(node) {
+			return nil, fmt.Errorf("%s: index %s out of range", path, key)
+		}
+		return node[index], nil
+	default:
+		return nil, fmt.Errorf("%s: cannot descend into %q", path, key)
+	}
+}
+
+// children returns the elements of an array or the member values of an object in key order
+func children(node interface{}) []interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		values := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, node[key])
+		}
+		return values
+	case []interface{}:
+		return node
+	default:
+		return nil
+	}
+}
+
+// descendantValues returns the values of a key at any depth below node, in document order
+func descendantValues(node interface{}, key string) []interface{} {
+	var values []interface{}
+	if object, ok := node.(map[string]interface{}); ok {
+		if value, ok := object[key]; ok {
+			values = append(values, value)
+		}
+	}
+	for _, child := range children(node) {
+		values = append(values, descendantValues(child, key)...)
+	}
+	return values
+}
+
+// parseJSONPath splits a JSONPath or jq-style expression into keys, indexes, wildcards
+// and recursive descents
+func parseJSONPath(path string) ([]pathSegment, error) {
+	rest := strings.TrimSpace(path)
+	switch {
+	case strings.HasPrefix(rest, "$"):
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "."):
+		// jq-style: "." is the document itself
+		if rest == "." {
+			rest = ""
+		}
+	default:
+		return nil, fmt.Errorf("JSONPath must start with $ (or . for jq-style paths): %s", path)
+	}
+
+	var segments []pathSegment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			recursive := false
+			if strings.HasPrefix(rest, ".") {
+				recursive = true
+				rest = rest[1:]
+			}
+			if strings.HasPrefix(rest, "[") && !recursive {
+				continue // jq-style .[0] and .["key"]
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in JSONPath: %s", path)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+			switch {
+			case key == "*" && !recursive:
+				segments = append(segments, pathSegment{wildcard: true})
+			case recursive:
+				segments = append(segments, pathSegment{key: key, recursive: true})
+			default:
+				segments = append(segments, pathSegment{key: key})
+			}
+		case '[':
+			end := strings.Index(rest, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in JSONPath: %s", path)
+			}
+			key := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if key == "" || key == "*" {
+				segments = append(segments, pathSegment{wildcard: true})
+			} else {
+				segments = append(segments, pathSegment{key: strings.Trim(key, `'"`)})
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q in JSONPath: %s", rest[0], path)
+		}
+	}
+
+	return segments, nil
+}
+
+// jsonValueString renders an extracted value: strings as-is, everything else as JSON
+func jsonValueString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
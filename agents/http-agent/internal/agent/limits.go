@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrBusy is returned when the cap on concurrent outbound requests or LLM calls is reached
+var ErrBusy = errors.New("too many concurrent requests")
+
+// defaultQueueTimeout is how long a call waits for a free slot when no timeout is configured
+const defaultQueueTimeout = 5 * time.Second
+
+// concurrencyLimiter caps concurrent operations; callers wait up to the queue timeout for a slot
+type concurrencyLimiter struct {
+	name    string
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// newConcurrencyLimiter creates a limiter, or returns nil (no limit) when max is not positive
+func newConcurrencyLimiter(name string, max int, timeout time.Duration) *concurrencyLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{name: name, slots: make(chan struct{}, max), timeout: timeout}
+}
+
+// acquire waits for a free slot and returns the function releasing it
+func (l *concurrencyLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%w: all %d %s slots are in use", ErrBusy, cap(l.slots), l.name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release frees a slot
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// acquireUpTo waits for one slot, then takes up to n-1 more that are free, and returns how
+// many it took with the function releasing them all
+func (l *concurrencyLimiter) acquireUpTo(ctx context.Context, n int) (int, func(), error) {
+	if l == nil {
+		return n, func() {}, nil
+	}
+
+	if _, err := l.acquire(ctx); err != nil {
+		return 0, nil, err
+	}
+	taken := 1
+fill:
+	for taken < n {
+		select {
+		case l.slots <- struct{}{}:
+			taken++
+		default:
+			break fill
+		}
+	}
+	return taken, func() {
+		for range taken {
+			l.release()
+		}
+	}, nil
+}
+
+// queueTimeout returns the configured wait for a free slot
+func queueTimeout(config *models.LimitsConfig) time.Duration {
+	if config.QueueTimeout <= 0 {
+		return defaultQueueTimeout
+	}
+	return time.Duration(config.QueueTimeout) * time.Second
+}
+
+// limitedLLMClient caps the number of concurrent LLM calls
+type limitedLLMClient struct {
+	LLMClient
+	limiter *concurrencyLimiter
+}
+
+// newLimitedLLMClient creates an LLM client whose calls take a slot of the limiter, if any
+func newLimitedLLMClient(config *models.LLMConfig, limiter *concurrencyLimiter) (LLMClient, error) {
+	client, err := NewLLMClient(config)
+	if err != nil {
+		return nil, err
+	}
+	if limiter == nil {
+		return client, nil
+	}
+	return &limitedLLMClient{LLMClient: client, limiter: limiter}, nil
+}
+
+// Chat waits for a free slot before chatting
+func (c *limitedLLMClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return c.LLMClient.Chat(ctx, systemPrompt, messages)
+}
@@ -7,15 +7,19 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
 )
 
+// maxPromptFrames limits how many WebSocket frames are included in the prompt
+const maxPromptFrames = 50
+
 // LLMClient defines the interface for LLM providers
 type LLMClient interface {
-	Analyze(ctx context.Context, request *models.RequestConfig, response *models.Response, prompt string) (string, error)
+	Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error)
 }
 
 // OpenAIClient implements LLM client for OpenAI
@@ -39,11 +43,27 @@ type GeminiClient struct {
 	client *http.Client
 }
 
+// MistralClient implements LLM client for Mistral AI
+type MistralClient struct {
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+// CohereClient implements LLM client for Cohere
+type CohereClient struct {
+	apiKey string
+	model  string
+	client *http.Client
+}
+
 // OllamaClient implements LLM client for Ollama (local LLM)
 type OllamaClient struct {
-	baseURL string
-	model   string
-	client  *http.Client
+	baseURL   string
+	model     string
+	keepAlive string // How long the model stays loaded after a call; empty for the server default
+	numCtx    int    // Context window in tokens; 0 for the model default
+	client    *http.Client
 }
 
 // LMStudioClient implements LLM client for LM Studio (OpenAI-compatible)
@@ -53,8 +73,26 @@ type LMStudioClient struct {
 	client  *http.Client
 }
 
+// OpenAICompatibleClient implements LLM client for any server exposing the OpenAI
+// chat completions API (OpenRouter, Groq, vLLM, llama.cpp server, ...)
+type OpenAICompatibleClient struct {
+	baseURL string // Including the API version, e.g. https://openrouter.ai/api/v1
+	apiKey  string // Optional
+	model   string
+	client  *http.Client
+}
+
 // NewLLMClient creates a new LLM client based on the provider
 func NewLLMClient(config *models.LLMConfig) (LLMClient, error) {
+	client, err := newProviderClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedLLMClient{LLMClient: client, provider: strings.ToLower(config.Provider), model: config.Model}, nil
+}
+
+// newProviderClient creates the client of the configured provider
+func newProviderClient(config *models.LLMConfig) (LLMClient, error) {
 	switch strings.ToLower(config.Provider) {
 	case "openai":
 		if config.APIKey == "" {
@@ -67,7 +105,7 @@ func NewLLMClient(config *models.LLMConfig) (LLMClient, error) {
 		return &OpenAIClient{
 			apiKey: config.APIKey,
 			model:  model,
-			client: &http.Client{Timeout: 30 * time.Second},
+			client: newLLMHTTPClient(30 * time.Second),
 		}, nil
 	case "anthropic", "claude":
 		if config.APIKey == "" {
@@ -80,7 +118,7 @@ func NewLLMClient(config *models.LLMConfig) (LLMClient, error) {
 		return &AnthropicClient{
 			apiKey: config.APIKey,
 			model:  model,
-			client: &http.Client{Timeout: 30 * time.Second},
+			client: newLLMHTTPClient(30 * time.Second),
 		}, nil
 	case "gemini", "google":
 		if config.APIKey == "" {
@@ -93,7 +131,33 @@ func NewLLMClient(config *models.LLMConfig) (LLMClient, error) {
 		return &GeminiClient{
 			apiKey: config.APIKey,
 			model:  model,
-			client: &http.Client{Timeout: 30 * time.Second},
+			client: newLLMHTTPClient(30 * time.Second),
+		}, nil
+	case "mistral":
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("Mistral API key is required")
+		}
+		model := config.Model
+		if model == "" {
+			model = "mistral-large-latest"
+		}
+		return &MistralClient{
+			apiKey: config.APIKey,
+			model:  model,
+			client: newLLMHTTPClient(30 * time.Second),
+		}, nil
+	case "cohere":
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("Cohere API key is required")
+		}
+		model := config.Model
+		if model == "" {
+			model = "command-r-plus"
+		}
+		return &CohereClient{
+			apiKey: config.APIKey,
+			model:  model,
+			client: newLLMHTTPClient(30 * time.Second),
 		}, nil
 	case "ollama":
 		baseURL := config.BaseURL
@@ -105,9 +169,11 @@ func NewLLMClient(config *models.LLMConfig) (LLMClient, error) {
 			model = "llama2"
 		}
 		return &OllamaClient{
-			baseURL: baseURL,
-			model:   model,
-			client:  &http.Client{Timeout: 60 * time.Second}, // Longer timeout for local models
+			baseURL:   baseURL,
+			model:     model,
+			keepAlive: strings.TrimSpace(config.KeepAlive),
+			numCtx:    config.NumCtx,
+			client:    newLLMHTTPClient(60 * time.Second), // Longer timeout for local models
 		}, nil
 	case "lmstudio", "lm-studio":
 		baseURL := config.BaseURL
@@ -121,26 +187,38 @@ func NewLLMClient(config *models.LLMConfig) (LLMClient, error) {
 		return &LMStudioClient{
 			baseURL: baseURL,
 			model:   model,
-			client:  &http.Client{Timeout: 60 * time.Second},
+			client:  newLLMHTTPClient(60 * time.Second),
+		}, nil
+	case "openai-compatible":
+		if config.BaseURL == "" {
+			return nil, fmt.Errorf("base URL is required for the openai-compatible provider")
+		}
+		if config.Model == "" {
+			return nil, fmt.Errorf("model is required for the openai-compatible provider")
+		}
+		return &OpenAICompatibleClient{
+			baseURL: strings.TrimRight(config.BaseURL, "/"),
+			apiKey:  config.APIKey,
+			model:   config.Model,
+			client:  newLLMHTTPClient(60 * time.Second),
 		}, nil
 	default:
-		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: openai, anthropic, gemini, ollama, lmstudio)", config.Provider)
+		return nil, fmt.Errorf("unsupported LLM provider: %s (supported: openai, anthropic, gemini, mistral, cohere, ollama, lmstudio, openai-compatible)", config.Provider)
 	}
 }
 
-// Analyze uses OpenAI to analyze the HTTP request/response
-func (c *OpenAIClient) Analyze(ctx context.Context, request *models.RequestConfig, response *models.Response, prompt string) (string, error) {
-	systemPrompt := buildSystemPrompt()
-	userPrompt := buildUserPrompt(request, response, prompt)
+// Chat sends a conversation to OpenAI and returns the assistant reply
+func (c *OpenAIClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	return chatCompletion(ctx, c.client, "OpenAI", "https://api.openai.com/v1/chat/completions", c.apiKey, c.model, systemPrompt, messages)
+}
 
+// Chat sends a conversation to Anthropic Claude and returns the assistant reply
+func (c *AnthropicClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
 	reqBody := map[string]interface{}{
-		"model": c.model,
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": userPrompt},
-		},
-		"temperature": 0.7,
-		"max_tokens":  1000,
+		"model":      c.model,
+		"max_tokens": 1024,
+		"system":     systemPrompt,
+		"messages":   messages,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -148,55 +226,75 @@ func (c *OpenAIClient) Analyze(ctx context.Context, request *models.RequestConfi
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call OpenAI API: %w", err)
+		return "", fmt.Errorf("failed to call Anthropic API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("OpenAI API error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("Anthropic API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	recordTokenUsage(ctx, result.Usage.InputTokens, result.Usage.OutputTokens)
 
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+	if len(result.Content) == 0 {
+		return "", fmt.Errorf("no response from Anthropic")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	return result.Content[0].Text, nil
 }
 
-// Analyze uses Anthropic Claude to analyze the HTTP request/response
-func (c *AnthropicClient) Analyze(ctx context.Context, request *models.RequestConfig, response *models.Response, prompt string) (string, error) {
-	systemPrompt := buildSystemPrompt()
-	userPrompt := buildUserPrompt(request, response, prompt)
+// Chat sends a conversation to Google Gemini and returns the model reply
+func (c *GeminiClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	// Gemini uses a different request structure: "model" instead of "assistant",
+	// and the system prompt is prepended to the first user turn
+	contents := make([]map[string]interface{}, 0, len(messages))
+	for i, msg := range messages {
+		role := msg.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		text := msg.Content
+		if i == 0 {
+			text = systemPrompt + "\n\n" + text
+		}
+		contents = append(contents, map[string]interface{}{
+			"role": role,
+			"parts": []map[string]string{
+				{"text": text},
+			},
+		})
+	}
 
 	reqBody := map[string]interface{}{
-		"model": c.model,
-		"max_tokens": 1024,
-		"system": systemPrompt,
-		"messages": []map[string]string{
-			{"role": "user", "content": userPrompt},
+		"contents": contents,
+		"generationConfig": map[string]interface{}{
+			"temperature":     0.7,
+			"maxOutputTokens": 1024,
 		},
 	}
 
@@ -205,61 +303,65 @@ func (c *AnthropicClient) Analyze(ctx context.Context, request *models.RequestCo
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
+	// Gemini API URL includes the API key as a query parameter
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", c.model, c.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", c.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call Anthropic API: %w", err)
+		return "", fmt.Errorf("failed to call Gemini API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Anthropic API error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("Gemini API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
-		Content []struct {
-			Text string `json:"text"`
-		} `json:"content"`
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	recordTokenUsage(ctx, result.UsageMetadata.PromptTokenCount, result.UsageMetadata.CandidatesTokenCount)
 
-	if len(result.Content) == 0 {
-		return "", fmt.Errorf("no response from Anthropic")
+	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("no response from Gemini")
 	}
 
-	return result.Content[0].Text, nil
+	return result.Candidates[0].Content.Parts[0].Text, nil
 }
 
-// Analyze uses Google Gemini to analyze the HTTP request/response
-func (c *GeminiClient) Analyze(ctx context.Context, request *models.RequestConfig, response *models.Response, prompt string) (string, error) {
-	systemPrompt := buildSystemPrompt()
-	userPrompt := buildUserPrompt(request, response, prompt)
+// Chat sends a conversation to Mistral AI and returns the assistant reply
+func (c *MistralClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	return chatCompletion(ctx, c.client, "Mistral", "https://api.mistral.ai/v1/chat/completions", c.apiKey, c.model, systemPrompt, messages)
+}
 
-	// Gemini uses a different request structure
+// Chat sends a conversation to the Cohere v2 chat API and returns the assistant reply
+func (c *CohereClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
 	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"parts": []map[string]string{
-					{"text": systemPrompt + "\n\n" + userPrompt},
-				},
-			},
-		},
-		"generationConfig": map[string]interface{}{
-			"temperature":     0.7,
-			"maxOutputTokens": 1024,
-		},
+		"model":       c.model,
+		"messages":    chatCompletionMessages(systemPrompt, messages),
+		"temperature": 0.7,
+		"max_tokens":  1000,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -267,60 +369,75 @@ func (c *GeminiClient) Analyze(ctx context.Context, request *models.RequestConfi
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Gemini API URL includes the API key as a query parameter
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", c.model, c.apiKey)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.cohere.com/v2/chat", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call Gemini API: %w", err)
+		return "", fmt.Errorf("failed to call Cohere API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Gemini API error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("Cohere API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
 			} `json:"content"`
-		} `json:"candidates"`
+		} `json:"message"`
+		Usage struct {
+			Tokens struct {
+				InputTokens  float64 `json:"input_tokens"`
+				OutputTokens float64 `json:"output_tokens"`
+			} `json:"tokens"`
+		} `json:"usage"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	recordTokenUsage(ctx, int(result.Usage.Tokens.InputTokens), int(result.Usage.Tokens.OutputTokens))
 
-	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no response from Gemini")
+	var sb strings.Builder
+	for _, part := range result.Message.Content {
+		if part.Type == "text" {
+			sb.WriteString(part.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("no response from Cohere")
 	}
 
-	return result.Candidates[0].Content.Parts[0].Text, nil
+	return sb.String(), nil
 }
 
-// Analyze uses Ollama to analyze the HTTP request/response
-func (c *OllamaClient) Analyze(ctx context.Context, request *models.RequestConfig, response *models.Response, prompt string) (string, error) {
-	systemPrompt := buildSystemPrompt()
-	userPrompt := buildUserPrompt(request, response, prompt)
-
+// Chat sends a conversation to Ollama and returns the generated reply
+func (c *OllamaClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	options := map[string]interface{}{
+		"temperature": 0.7,
+	}
+	if c.numCtx > 0 {
+		options["num_ctx"] = c.numCtx
+	}
 	reqBody := map[string]interface{}{
-		"model":  c.model,
-		"prompt": systemPrompt + "\n\n" + userPrompt,
-		"stream": false,
-		"options": map[string]interface{}{
-			"temperature": 0.7,
-		},
+		"model":    c.model,
+		"messages": chatCompletionMessages(systemPrompt, messages),
+		"stream":   false,
+		"options":  options,
+	}
+	if c.keepAlive != "" {
+		reqBody["keep_alive"] = ollamaKeepAlive(c.keepAlive)
 	}
 
 	jsonData, err := json.Marshal(reqBody)
@@ -328,7 +445,7 @@ func (c *OllamaClient) Analyze(ctx context.Context, request *models.RequestConfi
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := c.baseURL + "/api/generate"
+	url := c.baseURL + "/api/chat"
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -342,38 +459,58 @@ func (c *OllamaClient) Analyze(ctx context.Context, request *models.RequestConfi
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("Ollama model %q not found: pull it with \"ollama pull %s\" or pick an installed model", c.model, c.model)
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return "", fmt.Errorf("Ollama API error (status %d): %s", resp.StatusCode, string(body))
 	}
 
 	var result struct {
-		Response string `json:"response"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		PromptEvalCount int `json:"prompt_eval_count"`
+		EvalCount       int `json:"eval_count"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	recordTokenUsage(ctx, result.PromptEvalCount, result.EvalCount)
 
-	if result.Response == "" {
+	if result.Message.Content == "" {
 		return "", fmt.Errorf("no response from Ollama")
 	}
 
-	return result.Response, nil
+	return result.Message.Content, nil
 }
 
-// Analyze uses LM Studio to analyze the HTTP request/response
-// LM Studio uses OpenAI-compatible API
-func (c *LMStudioClient) Analyze(ctx context.Context, request *models.RequestConfig, response *models.Response, prompt string) (string, error) {
-	systemPrompt := buildSystemPrompt()
-	userPrompt := buildUserPrompt(request, response, prompt)
+// ollamaKeepAlive passes a keep-alive given in seconds (e.g. "-1" to keep the model loaded) as
+// a number, and durations such as "10m" as they are
+func ollamaKeepAlive(value string) interface{} {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return seconds
+	}
+	return value
+}
+
+// Chat sends a conversation to LM Studio and returns the assistant reply
+func (c *LMStudioClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	return chatCompletion(ctx, c.client, "LM Studio", c.baseURL+"/v1/chat/completions", "", c.model, systemPrompt, messages)
+}
+
+// Chat sends a conversation to an OpenAI-compatible server and returns the assistant reply
+func (c *OpenAICompatibleClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	return chatCompletion(ctx, c.client, "OpenAI-compatible", c.baseURL+"/chat/completions", c.apiKey, c.model, systemPrompt, messages)
+}
 
+// chatCompletion calls an OpenAI-style chat completions endpoint; the API key is optional
+func chatCompletion(ctx context.Context, client *http.Client, provider, url, apiKey, model, systemPrompt string, messages []models.ChatMessage) (string, error) {
 	reqBody := map[string]interface{}{
-		"model": c.model,
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": userPrompt},
-		},
+		"model":       model,
+		"messages":    chatCompletionMessages(systemPrompt, messages),
 		"temperature": 0.7,
 		"max_tokens":  1000,
 	}
@@ -383,23 +520,25 @@ func (c *LMStudioClient) Analyze(ctx context.Context, request *models.RequestCon
 		return "", fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	url := c.baseURL + "/v1/chat/completions"
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
 
-	resp, err := c.client.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to call LM Studio API: %w", err)
+		return "", fmt.Errorf("failed to call %s API: %w", provider, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("LM Studio API error (status %d): %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("%s API error (status %d): %s", provider, resp.StatusCode, string(body))
 	}
 
 	var result struct {
@@ -408,89 +547,168 @@ func (c *LMStudioClient) Analyze(ctx context.Context, request *models.RequestCon
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", fmt.Errorf("failed to decode response: %w", err)
 	}
+	recordTokenUsage(ctx, result.Usage.PromptTokens, result.Usage.CompletionTokens)
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from LM Studio")
+		return "", fmt.Errorf("no response from %s", provider)
 	}
 
 	return result.Choices[0].Message.Content, nil
 }
 
-// buildSystemPrompt creates the system prompt for the LLM
-func buildSystemPrompt() string {
-	return `You are an intelligent HTTP debugging and analysis assistant. Your role is to help users understand HTTP requests and responses.
-
-When analyzing requests and responses:
-- Provide clear, concise answers in natural language
-- Format JSON responses with proper indentation
-- Explain HTTP status codes and their meanings
-- Identify common issues (CORS, authentication, rate limits, etc.)
-- Suggest improvements when appropriate
-- Use simple terms for technical concepts
-- Be specific about what you observe in the data
-
-If the user asks about the status code, explain what it means.
-If they ask if a URL is accessible, check the status code (2xx = success, 4xx = client error, 5xx = server error).
-If they ask about timing, provide context (< 100ms = fast, 100-500ms = moderate, > 500ms = slow).
-If they ask about content, format it nicely and highlight key information.`
+// chatCompletionMessages builds an OpenAI-style message list with a leading system message
+func chatCompletionMessages(systemPrompt string, messages []models.ChatMessage) []map[string]string {
+	result := make([]map[string]string, 0, len(messages)+1)
+	result = append(result, map[string]string{"role": "system", "content": systemPrompt})
+	for _, msg := range messages {
+		result = append(result, map[string]string{"role": msg.Role, "content": msg.Content})
+	}
+	return result
 }
 
-// buildUserPrompt creates the user prompt with request/response details
-func buildUserPrompt(request *models.RequestConfig, response *models.Response, userQuestion string) string {
-	var sb strings.Builder
+// userQuestion returns the question to ask, falling back to the default one
+func userQuestion(question string) string {
+	if question == "" {
+		return "What is the status code of this request?"
+	}
+	return question
+}
+
+// buildRequestBuilderPrompt creates the system prompt for turning a description into a request
+func buildRequestBuilderPrompt() string {
+	return `You convert natural-language descriptions of HTTP requests into a structured request definition.
+
+Respond with a single JSON object and nothing else, using exactly these fields:
+{
+  "url": "full URL including scheme (default to https:// when none is given)",
+  "method": "GET, POST, PUT, PATCH, DELETE, HEAD or OPTIONS",
+  "headers": {"Header-Name": "value"},
+  "body": "request body as a string (JSON bodies must be serialized), or empty",
+  "prompt": "the question the user wants answered about the response, or empty"
+}
+
+Rules:
+- Add "Content-Type: application/json" when the body is JSON
+- Turn "bearer token X" into "Authorization: Bearer X"
+- Only include headers and body content the user asked for
+- Never invent hosts, credentials or values that were not mentioned`
+}
 
-	sb.WriteString("HTTP Request and Response Analysis:\n\n")
-	sb.WriteString(fmt.Sprintf("Request:\n"))
-	sb.WriteString(fmt.Sprintf("- Method: %s\n", request.Method))
-	sb.WriteString(fmt.Sprintf("- URL: %s\n", request.URL))
+// buildInvestigationPrompt extends a system prompt with the multi-step investigation protocol
+func buildInvestigationPrompt(systemPrompt string, maxSteps int) string {
+	return systemPrompt + fmt.Sprintf(`
 
-	if len(request.Headers) > 0 {
-		sb.WriteString("- Headers:\n")
-		for k, v := range request.Headers {
-			sb.WriteString(fmt.Sprintf("  %s: %s\n", k, v))
+You can investigate further before answering by issuing up to %d follow-up HTTP requests to the same host.
+Useful checks include fetching /robots.txt, calling the OPTIONS method, or retrying with different headers.
+
+To issue a request, reply with a single JSON object and nothing else:
+{"tool": "http_request", "reason": "why this request helps", "arguments": {"url": "/path or full URL", "method": "GET", "headers": {}, "body": ""}}
+
+Each request result is sent back to you. Only issue requests that add information.
+Values shown as {{name}} are masked secrets: reuse the same placeholders in your requests and they are filled in.
+When you have enough information, reply with your final diagnosis in plain text, consolidating what every request revealed.`, maxSteps)
+}
+
+// promptDetails describes the protocol-specific parts of an exchange for the user prompt
+func promptDetails(request *models.RequestConfig, response *models.Response) string {
+	var sb strings.Builder
+
+	if response.ContentType == grpcContentType {
+		sb.WriteString("- Note: this is a gRPC call; the status is a gRPC status code (0 = OK), not an HTTP status code\n")
+	}
+
+	if len(response.Frames) > 0 {
+		sb.WriteString("- WebSocket Frames:\n")
+		for i, frame := range response.Frames {
+			if i == maxPromptFrames {
+				sb.WriteString(fmt.Sprintf("  ... %d more frames (truncated)\n", len(response.Frames)-maxPromptFrames))
+				break
+			}
+			data := frame.Data
+			if len(data) > 200 {
+				data = data[:200] + "... (truncated)"
+			}
+			sb.WriteString(fmt.Sprintf("  [%s] %s %s: %s\n", frame.Offset, frame.Direction, frame.Type, data))
 		}
 	}
 
-	if request.Body != "" {
-		bodyPreview := request.Body
-		if len(bodyPreview) > 500 {
-			bodyPreview = bodyPreview[:500] + "... (truncated)"
+	// Add documented contract when the request is linked to an OpenAPI operation
+	if request.OpenAPI != nil && len(request.OpenAPI.ResponseSchemas) > 0 {
+		schemas, err := json.MarshalIndent(request.OpenAPI.ResponseSchemas, "", "  ")
+		if err == nil {
+			schemaText := string(schemas)
+			if len(schemaText) > 3000 {
+				schemaText = schemaText[:3000] + "... (truncated)"
+			}
+			sb.WriteString(fmt.Sprintf("\nDocumented Responses (OpenAPI operation %s):\n%s\n", request.OpenAPI.OperationID, schemaText))
+			sb.WriteString("Check whether the actual response conforms to the documented schema for its status code and list any mismatches (missing or extra fields, wrong types, undocumented status codes).\n")
 		}
-		sb.WriteString(fmt.Sprintf("- Body: %s\n", bodyPreview))
 	}
 
-	sb.WriteString(fmt.Sprintf("\nResponse:\n"))
-	sb.WriteString(fmt.Sprintf("- Status: %d %s\n", response.StatusCode, response.Status))
-	sb.WriteString(fmt.Sprintf("- Duration: %s\n", FormatDuration(response.Duration)))
-	sb.WriteString(fmt.Sprintf("- Content-Type: %s\n", response.ContentType))
-	sb.WriteString(fmt.Sprintf("- Content-Length: %d bytes\n", response.ContentLength))
+	// Add GraphQL context, where failures are reported in the body rather than the status code
+	if request.GraphQL != nil {
+		query := request.GraphQL.Query
+		if len(query) > 1000 {
+			query = query[:1000] + "... (truncated)"
+		}
+		sb.WriteString(fmt.Sprintf("\nGraphQL Operation:\n%s\n", query))
+		if len(request.GraphQL.Variables) > 0 {
+			if variables, err := json.Marshal(request.GraphQL.Variables); err == nil {
+				sb.WriteString(fmt.Sprintf("- Variables: %s\n", variables))
+			}
+		}
+
+		if errs := graphQLErrors(response.Body); len(errs) > 0 {
+			sb.WriteString("- GraphQL Errors:\n")
+			for _, e := range errs {
+				sb.WriteString(fmt.Sprintf("  - %s\n", e))
+			}
+		} else {
+			sb.WriteString("- GraphQL Errors: none\n")
+		}
 
-	if len(response.Headers) > 0 {
-		sb.WriteString("- Response Headers:\n")
-		for k, v := range response.Headers {
-			sb.WriteString(fmt.Sprintf("  %s: %s\n", k, strings.Join(v, ", ")))
+		if request.GraphQL.Schema != "" {
+			sb.WriteString(fmt.Sprintf("\nGraphQL Schema (introspection):\n%s\n", request.GraphQL.Schema))
 		}
+		sb.WriteString("GraphQL servers usually answer 200 OK even when an operation fails: judge the outcome by the errors array and whether data is null, not only by the HTTP status code.\n")
 	}
 
-	if response.Body != "" {
-		bodyPreview := response.Body
-		if len(bodyPreview) > 1000 {
-			bodyPreview = bodyPreview[:1000] + "... (truncated)"
+	// Add the deterministic security header audit when the caller asks for it
+	if request.SecurityAudit {
+		if report := auditSecurityHeaders(request.URL, response); report != nil {
+			sb.WriteString(fmt.Sprintf("\nSecurity Header Audit:\n%s", describeSecurityReport(report)))
+			sb.WriteString("Explain the failed checks, their risk, and the header values that would fix them.\n")
 		}
-		sb.WriteString(fmt.Sprintf("- Response Body:\n%s\n", bodyPreview))
 	}
 
-	// Add user question
-	if userQuestion == "" {
-		userQuestion = "What is the status code of this request?"
+	// Add the TLS configuration grade of HTTPS targets
+	if response.TLS != nil {
+		sb.WriteString(fmt.Sprintf("\nTLS Configuration:\n%s", describeTLSReport(response.TLS)))
+		sb.WriteString("Point out weak TLS settings (old protocol versions, insecure cipher suites, missing forward secrecy) when they are relevant to the question.\n")
+	}
+
+	// Add the reachability probes so network problems can be told from application errors
+	if request.Reachability != nil && request.Reachability.Report != nil {
+		sb.WriteString(fmt.Sprintf("\nNetwork Reachability:\n%s", describeReachability(request.Reachability.Report)))
+		sb.WriteString("Use these probes to say whether a problem lies in the network path (refused or lost connections, packet loss, high latency) or in the application itself.\n")
+	}
+
+	// Add the caller's assertion results so failures can be explained
+	if assertions := evaluateAssertions(request.Assertions, response); len(assertions) > 0 {
+		sb.WriteString(fmt.Sprintf("\nAssertions:\n%s", describeAssertions(assertions)))
+		if !assertionsPassed(assertions) {
+			sb.WriteString("Explain the likely cause of each failing assertion.\n")
+		}
 	}
-	sb.WriteString(fmt.Sprintf("\nUser Question: %s\n", userQuestion))
-	sb.WriteString("\nProvide a clear and helpful answer:")
 
 	return sb.String()
 }
@@ -0,0 +1,378 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+var (
+	// ErrLoadTestDisabled is returned when load testing is not enabled in the configuration
+	ErrLoadTestDisabled = errors.New("load testing is disabled (set load_test.enabled)")
+
+	// ErrLoadTestNotAllowed is returned when the target host is not in the load test allowlist
+	ErrLoadTestNotAllowed = errors.New("host is not in load_test.allowed_hosts")
+)
+
+const (
+	// defaultLoadTestConcurrency is used when no worker count is given
+	defaultLoadTestConcurrency = 10
+
+	// defaultLoadTestRequests is used when neither a request count nor a duration is given
+	defaultLoadTestRequests = 100
+
+	// maxLoadTestErrors limits the distinct error messages reported
+	maxLoadTestErrors = 10
+
+	// maxLoadTestConcurrency, maxLoadTestRequests and maxLoadTestDuration bound a test even
+	// when the configured caps are turned off
+	maxLoadTestConcurrency = 1000
+	maxLoadTestRequests    = 1000000
+	maxLoadTestDuration    = 3600
+
+	// loadTestSampleCapacity is the most samples preallocated; longer tests grow the slice
+	loadTestSampleCapacity = 10000
+)
+
+// loadTestSample is the outcome of a single load test request
+type loadTestSample struct {
+	status   int
+	duration time.Duration
+	err      error
+}
+
+// RunLoadTest sends a request repeatedly from concurrent workers and reports latency
+// percentiles, error rates and throughput with an LLM interpretation
+func (a *HTTPAgent) RunLoadTest(ctx context.Context, loadReq *models.LoadTestRequest) (*models.LoadTestResult, error) {
+	if !a.loadTest.Enabled {
+		return nil, ErrLoadTestDisabled
+	}
+	if err := validateLoadTest(loadReq); err != nil {
+		return nil, err
+	}
+
+	reqConfig := loadReq.Request
+	if reqConfig.GraphQL != nil {
+		if err := prepareGraphQLRequest(&reqConfig); err != nil {
+			return nil, err
+		}
+	}
+	var secrets map[string]string
+	if reqConfig.Environment != "" {
+		var err error
+		if secrets, err = a.applyEnvironment(&reqConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	parsedURL, err := url.Parse(reqConfig.URL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return nil, fmt.Errorf("load tests support http and https URLs only")
+	}
+	if !hostAllowed(parsedURL.Hostname(), a.loadTest.AllowedHosts) {
+		return nil, fmt.Errorf("%w: %s", ErrLoadTestNotAllowed, parsedURL.Hostname())
+	}
+	if err := a.httpClient.validateURL(reqConfig.URL); err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	// Resolve the credentials (fetching an OAuth2 token) once for the whole run
+	sent, credentials, err := a.httpClient.authenticate(ctx, &reqConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) > 0 && secrets == nil {
+		secrets = make(map[string]string, len(credentials))
+	}
+	for value, mask := range credentials {
+		secrets[value] = mask
+	}
+
+	concurrency := clampLimit(loadReq.Concurrency, defaultLoadTestConcurrency, a.loadTest.MaxConcurrency)
+	requests, duration := loadReq.Requests, loadReq.Duration
+	if requests <= 0 && duration <= 0 {
+		requests = defaultLoadTestRequests
+	}
+	if a.loadTest.MaxRequests > 0 && (requests <= 0 || requests > a.loadTest.MaxRequests) {
+		requests = a.loadTest.MaxRequests
+	}
+	if a.loadTest.MaxDuration > 0 && (duration <= 0 || duration > a.loadTest.MaxDuration) {
+		duration = a.loadTest.MaxDuration
+	}
+
+	testCtx := ctx
+	if duration > 0 {
+		var cancel context.CancelFunc
+		testCtx, cancel = context.WithTimeout(ctx, time.Duration(duration)*time.Second)
+		defer cancel()
+	}
+
+	// Each worker takes a slot of the outbound request cap; the test runs with the free ones
+	concurrency, release, err := a.httpClient.limiter.acquireUpTo(ctx, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	start := time.Now()
+	samples, elapsed := a.httpClient.runLoad(testCtx, sent, concurrency, requests)
+	maskRequest(&reqConfig, secrets)
+
+	// The load test is audited as a whole; its requests bypass the audited transport
+	a.httpClient.audit.Record(ctx, models.AuditEntry{
+		Timestamp: start.UTC(),
+		Protocol:  "loadtest",
+		Method:    strings.ToUpper(reqConfig.Method),
+		URL:       reqConfig.URL,
+		Duration:  elapsed.Milliseconds(),
+		Requests:  len(samples),
+	})
+
+	result := summarizeLoadTest(samples, elapsed)
+	result.Request = &reqConfig
+	result.Concurrency = concurrency
+	for message, count := range result.Errors {
+		if masked := maskSecrets(message, secrets); masked != message {
+			delete(result.Errors, message)
+			result.Errors[masked] += count
+		}
+	}
+
+	analysis, err := a.chat(ctx, "", []models.ChatMessage{
+		{Role: "user", Content: buildLoadTestPrompt(result, loadReq.Prompt)},
+	})
+	if err != nil {
+		analysis = fmt.Sprintf("Analysis unavailable: %v", err)
+	}
+	result.Analysis = analysis
+
+	return result, nil
+}
+
+// runLoad sends the request from concurrent workers over a shared connection pool until
+// the request count is reached (0 means no limit) or the context ends
+func (c *HTTPClient) runLoad(ctx context.Context, reqConfig *models.RequestConfig, concurrency, requests int) ([]loadTestSample, time.Duration) {
+	verifySSL := c.config.VerifySSL
+	if reqConfig.VerifySSL != nil {
+		verifySSL = *reqConfig.VerifySSL
+	}
+	// Load test requests are not traced individually; the run is covered by the API request span
+	transport := c.newTransport(verifySSL)
+	transport.MaxIdleConnsPerHost = concurrency
+	defer transport.CloseIdleConnections()
+	client := c.newClient(transport)
+
+	var mu sync.Mutex
+	samples := make([]loadTestSample, 0, min(requests, loadTestSampleCapacity))
+	sent := 0
+
+	// next reserves a request slot; false once the count is reached or time is up
+	next := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil || (requests > 0 && sent >= requests) {
+			return false
+		}
+		sent++
+		return true
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next() {
+				sample := c.timedRequest(ctx, client, reqConfig)
+				// Requests cut off by the end of the test are not failures of the target
+				if sample.err != nil && ctx.Err() != nil {
+					return
+				}
+				mu.Lock()
+				samples = append(samples, sample)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return samples, time.Since(start)
+}
+
+// timedRequest sends one request and discards the body after reading it
+func (c *HTTPClient) timedRequest(ctx context.Context, client *http.Client, reqConfig *models.RequestConfig) loadTestSample {
+	start := time.Now()
+
+	var body io.Reader
+	if reqConfig.Body != "" {
+		body = bytes.NewBufferString(reqConfig.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, reqConfig.Method, reqConfig.URL, body)
+	if err != nil {
+		return loadTestSample{err: err}
+	}
+	for key, value := range reqConfig.Headers {
+		req.Header.Set(key, value)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", "Intelligent-HTTP-Agent/1.0")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return loadTestSample{err: err, duration: time.Since(start)}
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, io.LimitReader(resp.Body, c.maxResponseSize)); err != nil {
+		return loadTestSample{err: fmt.Errorf("failed to read response body: %w", err), duration: time.Since(start)}
+	}
+	return loadTestSample{status: resp.StatusCode, duration: time.Since(start)}
+}
+
+// summarizeLoadTest computes counts, error rate, throughput and latency percentiles
+func summarizeLoadTest(samples []loadTestSample, elapsed time.Duration) *models.LoadTestResult {
+	result := &models.LoadTestResult{
+		Requests:    len(samples),
+		Elapsed:     FormatDuration(elapsed),
+		StatusCodes: make(map[string]int),
+		Errors:      make(map[string]int),
+	}
+
+	latencies := make([]time.Duration, 0, len(samples))
+	for _, sample := range samples {
+		if sample.err != nil {
+			result.Failures++
+			message := sample.err.Error()
+			if _, ok := result.Errors[message]; ok || len(result.Errors) < maxLoadTestErrors {
+				result.Errors[message]++
+			}
+			continue
+		}
+		if sample.status >= 400 {
+			result.Failures++
+		}
+		result.StatusCodes[strconv.Itoa(sample.status)]++
+		latencies = append(latencies, sample.duration)
+	}
+
+	if result.Requests > 0 {
+		result.ErrorRate = float64(result.Failures) / float64(result.Requests)
+	}
+	if elapsed > 0 {
+		result.Throughput = float64(result.Requests) / elapsed.Seconds()
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var total time.Duration
+		for _, latency := range latencies {
+			total += latency
+		}
+		result.Latency = models.LatencyStats{
+			Min:  FormatDuration(latencies[0]),
+			Mean: FormatDuration(total / time.Duration(len(latencies))),
+			P50:  FormatDuration(percentile(latencies, 50)),
+			P90:  FormatDuration(percentile(latencies, 90)),
+			P95:  FormatDuration(percentile(latencies, 95)),
+			P99:  FormatDuration(percentile(latencies, 99)),
+			Max:  FormatDuration(latencies[len(latencies)-1]),
+		}
+	}
+
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// hostAllowed matches a host against allowlist entries; *.example.com matches subdomains
+func hostAllowed(host string, allowed []string) bool {
+	entries := make([]string, len(allowed))
+	for i, entry := range allowed {
+		entries[i] = strings.ToLower(strings.TrimSpace(entry))
+	}
+	_, ok := matchHost(strings.ToLower(host), entries)
+	return ok
+}
+
+// validateLoadTest rejects negative and absurdly large test sizes; zero selects the default
+func validateLoadTest(loadReq *models.LoadTestRequest) error {
+	for _, limit := range []struct {
+		name       string
+		value, max int
+	}{
+		{"concurrency", loadReq.Concurrency, maxLoadTestConcurrency},
+		{"requests", loadReq.Requests, maxLoadTestRequests},
+		{"duration", loadReq.Duration, maxLoadTestDuration},
+	} {
+		if limit.value < 0 || limit.value > limit.max {
+			return fmt.Errorf("%s must be between 0 and %d", limit.name, limit.max)
+		}
+	}
+	return nil
+}
+
+// clampLimit applies a default to unset values and caps them at the configured maximum
+func clampLimit(value, defaultValue, maxValue int) int {
+	if value <= 0 {
+		value = defaultValue
+	}
+	if maxValue > 0 && value > maxValue {
+		value = maxValue
+	}
+	return value
+}
+
+// buildLoadTestPrompt describes load test results for the LLM
+func buildLoadTestPrompt(result *models.LoadTestResult, question string) string {
+	var sb strings.Builder
+
+	sb.WriteString("Load Test Results:\n")
+	sb.WriteString(fmt.Sprintf("- Request: %s %s\n", result.Request.Method, result.Request.URL))
+	sb.WriteString(fmt.Sprintf("- Concurrency: %d workers\n", result.Concurrency))
+	sb.WriteString(fmt.Sprintf("- Requests: %d in %s (%.1f req/s)\n", result.Requests, result.Elapsed, result.Throughput))
+	sb.WriteString(fmt.Sprintf("- Failures: %d (%.1f%%)\n", result.Failures, result.ErrorRate*100))
+
+	codes := make([]string, 0, len(result.StatusCodes))
+	for code := range result.StatusCodes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		sb.WriteString(fmt.Sprintf("- Status %s: %d\n", code, result.StatusCodes[code]))
+	}
+	for message, count := range result.Errors {
+		sb.WriteString(fmt.Sprintf("- Error (%dx): %s\n", count, message))
+	}
+
+	latency := result.Latency
+	sb.WriteString(fmt.Sprintf("- Latency: min %s, mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		latency.Min, latency.Mean, latency.P50, latency.P90, latency.P95, latency.P99, latency.Max))
+
+	if question == "" {
+		question = "Interpret these results: is the service handling this load well, where does latency degrade, and what do the errors suggest?"
+	}
+	sb.WriteString(fmt.Sprintf("\nUser Question: %s\n", question))
+	sb.WriteString("\nProvide a clear and helpful answer:")
+
+	return sb.String()
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrInvalidModel is returned when a session selects an unknown provider or an unusable model
+var ErrInvalidModel = errors.New("invalid model selection")
+
+// modelListTimeout bounds the query of a local server for its models
+const modelListTimeout = 5 * time.Second
+
+// ListModels returns the configured LLM providers with the models they offer. Local servers
+// (Ollama, LM Studio and OpenAI-compatible) are asked for their installed models
+func (a *HTTPAgent) ListModels(ctx context.Context) []models.ProviderModels {
+	list := make([]models.ProviderModels, len(a.providers))
+	var wg sync.WaitGroup
+	for i, provider := range a.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list[i] = provider.models(ctx)
+		}()
+	}
+	wg.Wait()
+	return list
+}
+
+// SetSessionModel switches the LLM provider and model answering a session's follow-ups
+func (a *HTTPAgent) SetSessionModel(ctx context.Context, sessionID string, selection *models.ModelSelection) (*models.Session, error) {
+	if _, err := a.sessions.Get(sessionID); err != nil {
+		return nil, err
+	}
+
+	provider := a.provider(selection.Provider)
+	if provider == nil {
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidModel, selection.Provider)
+	}
+	model := strings.TrimSpace(selection.Model)
+	// Only listed models get a client, so callers cannot grow the client cache at will
+	if model != "" && model != provider.model {
+		listed := provider.models(ctx)
+		if listed.Error != "" {
+			return nil, fmt.Errorf("%w: cannot list the models of provider %q: %s", ErrInvalidModel, provider.name, listed.Error)
+		}
+		if !slices.Contains(listed.Models, model) {
+			return nil, fmt.Errorf("%w: provider %q does not list model %q", ErrInvalidModel, provider.name, model)
+		}
+	}
+	if _, err := provider.clientFor(model); err != nil {
+		return nil, err
+	}
+
+	if provider == a.providers[0] && (model == "" || model == provider.model) {
+		// Back to the main provider and model
+		return a.sessions.SetModel(sessionID, "", "")
+	}
+	return a.sessions.SetModel(sessionID, provider.name, model)
+}
+
+// sessionClient returns the LLM client selected for a session, the main one by default
+func (a *HTTPAgent) sessionClient(session *models.Session) (LLMClient, error) {
+	if session.Provider == "" {
+		return a.llmClient, nil
+	}
+	provider := a.provider(session.Provider)
+	if provider == nil {
+		return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidModel, session.Provider)
+	}
+	return provider.clientFor(session.Model)
+}
+
+// clientFor returns the provider's client for a model, creating and caching it on first use
+func (p *llmProvider) clientFor(model string) (LLMClient, error) {
+	if model == "" || model == p.model {
+		return p.client, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[model]; ok {
+		return client, nil
+	}
+	config := p.config
+	config.Model = model
+	client, err := newLimitedLLMClient(&config, p.limiter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+	p.clients[model] = client
+	return client, nil
+}
+
+// models lists the provider's models; hosted APIs only report the configured model
+func (p *llmProvider) models(ctx context.Context) models.ProviderModels {
+	entry := models.ProviderModels{
+		Name:     p.name,
+		Provider: strings.ToLower(p.config.Provider),
+		Model:    p.model,
+		Models:   []string{},
+	}
+
+	url, ok := localModelsURL(&p.config)
+	if !ok {
+		if p.model != "" {
+			entry.Models = append(entry.Models, p.model)
+		}
+		return entry
+	}
+
+	available, err := fetchLocalModels(ctx, url, p.config.APIKey)
+	if err != nil {
+		entry.Error = err.Error()
+		if p.model != "" {
+			entry.Models = append(entry.Models, p.model)
+		}
+		return entry
+	}
+	entry.Models = available
+	return entry
+}
+
+// localModelsURL returns the model listing endpoint of a local or self-hosted provider
+func localModelsURL(config *models.LLMConfig) (string, bool) {
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	switch strings.ToLower(config.Provider) {
+	case "ollama":
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		return baseURL + "/api/tags", true
+	case "lmstudio", "lm-studio":
+		if baseURL == "" {
+			baseURL = "http://localhost:1234"
+		}
+		return baseURL + "/v1/models", true
+	case "openai-compatible":
+		// The base URL already includes the API version
+		return baseURL + "/models", baseURL != ""
+	default:
+		return "", false
+	}
+}
+
+// fetchLocalModels queries a server for its models, understanding both Ollama's /api/tags and
+// the OpenAI-style /models responses
+func fetchLocalModels(ctx context.Context, url, apiKey string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, modelListTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := newLLMHTTPClient(modelListTimeout).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list models: %s", resp.Status)
+	}
+
+	var result struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"` // Ollama
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"` // OpenAI-style
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	names := make([]string, 0, len(result.Models)+len(result.Data))
+	for _, m := range result.Models {
+		names = append(names, m.Name)
+	}
+	for _, m := range result.Data {
+		names = append(names, m.ID)
+	}
+	return names, nil
+}
@@ -0,0 +1,495 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/audit"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrMonitorNotFound is returned when a monitor does not exist
+var ErrMonitorNotFound = errors.New("monitor not found")
+
+const (
+	// maxMonitors limits how many monitors can be scheduled
+	maxMonitors = 50
+
+	// defaultMonitorRuns is used when no run retention is configured
+	defaultMonitorRuns = 100
+
+	// latencyBaselineRuns is the number of healthy runs averaged for the latency baseline
+	latencyBaselineRuns = 10
+
+	// latencyRegressionFactor marks a run as slow compared with the recent average
+	latencyRegressionFactor = 3
+
+	// minLatencyRegression ignores slowdowns smaller than this many milliseconds
+	minLatencyRegression = 100
+
+	// maxShapePaths limits how much of a JSON body is tracked for structure changes
+	maxShapePaths = 200
+
+	// maxReportedShapeChanges limits the structure changes listed in a regression
+	maxReportedShapeChanges = 5
+)
+
+// Monitor states
+const (
+	monitorPending = "pending"
+	monitorHealthy = "healthy"
+	monitorFailing = "failing"
+)
+
+// MonitorStore keeps scheduled monitors and their recent runs in memory
+type MonitorStore struct {
+	mu       sync.Mutex
+	cron     *cron.Cron
+	maxRuns  int
+	smtp     models.SMTPConfig
+	monitors map[string]*monitorEntry
+}
+
+// monitorEntry is a registered monitor with its schedule and regression baseline
+type monitorEntry struct {
+	monitor  models.Monitor
+	runs     []models.MonitorRun // Newest first
+	entryID  cron.EntryID
+	baseline monitorBaseline
+}
+
+// monitorBaseline describes the last healthy behaviour of a monitored request
+type monitorBaseline struct {
+	status    int
+	shape     map[string]string // JSON paths and value types of the last healthy body
+	latencies []int64           // Recent healthy latencies in milliseconds
+}
+
+// NewMonitorStore creates a store and starts its scheduler
+func NewMonitorStore(config *models.MonitorConfig) *MonitorStore {
+	maxRuns := config.MaxRuns
+	if maxRuns <= 0 {
+		maxRuns = defaultMonitorRuns
+	}
+
+	// A monitor whose previous run is still in progress skips its next tick
+	scheduler := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)))
+	scheduler.Start()
+
+	return &MonitorStore{
+		cron:     scheduler,
+		maxRuns:  maxRuns,
+		smtp:     config.SMTP,
+		monitors: make(map[string]*monitorEntry),
+	}
+}
+
+// Stop stops the scheduler and waits for running monitors to finish
+func (s *MonitorStore) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+// snapshot copies a monitor with its current state; runs are included on request
+func (s *MonitorStore) snapshot(entry *monitorEntry, withRuns bool) models.Monitor {
+	monitor := entry.monitor
+	monitor.Request.Auth = redactAuth(monitor.Request.Auth)
+	if len(entry.runs) > 0 {
+		last := entry.runs[0]
+		monitor.LastRun = &last
+	}
+	if next := s.cron.Entry(entry.entryID).Next; !next.IsZero() {
+		monitor.NextRun = &next
+	}
+	if withRuns {
+		monitor.Runs = append([]models.MonitorRun(nil), entry.runs...)
+	}
+	return monitor
+}
+
+// CreateMonitor validates and schedules a monitor
+func (a *HTTPAgent) CreateMonitor(monitor *models.Monitor) (*models.Monitor, error) {
+	if _, err := cron.ParseStandard(monitor.Schedule); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", monitor.Schedule, err)
+	}
+	if strings.TrimSpace(monitor.Request.URL) == "" {
+		return nil, fmt.Errorf("request url is required")
+	}
+	monitor.Request.Method = strings.ToUpper(strings.TrimSpace(monitor.Request.Method))
+	if monitor.Request.Method == "" {
+		monitor.Request.Method = "GET"
+	}
+	if err := a.prompts.Validate(monitor.Request.Profile); err != nil {
+		return nil, err
+	}
+	if err := a.validateAlerts(monitor.Alerts); err != nil {
+		return nil, err
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate monitor ID: %w", err)
+	}
+	monitor.ID = id
+	if monitor.Name == "" {
+		monitor.Name = monitor.Request.Method + " " + monitor.Request.URL
+	}
+	monitor.CreatedAt = time.Now().UTC()
+	monitor.State = monitorPending
+	monitor.LastRun = nil
+	monitor.NextRun = nil
+	monitor.Runs = nil
+
+	a.monitors.mu.Lock()
+	defer a.monitors.mu.Unlock()
+
+	if len(a.monitors.monitors) >= maxMonitors {
+		return nil, fmt.Errorf("too many monitors (limit %d); delete one first", maxMonitors)
+	}
+
+	entryID, err := a.monitors.cron.AddFunc(monitor.Schedule, func() {
+		// Each scheduled run gets its own request ID so its outbound and LLM calls can be correlated
+		ctx := telemetry.WithRequestID(context.Background(), telemetry.NewRequestID())
+		ctx = audit.WithActor(ctx, audit.Actor{Source: audit.SourceMonitor, MonitorID: id})
+		if _, err := a.RunMonitor(ctx, id); err != nil && !errors.Is(err, ErrMonitorNotFound) {
+			slog.ErrorContext(ctx, "monitor run failed", "monitor_id", id, "error", err)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule monitor: %w", err)
+	}
+
+	entry := &monitorEntry{monitor: *monitor, entryID: entryID}
+	a.monitors.monitors[id] = entry
+
+	created := a.monitors.snapshot(entry, false)
+	return &created, nil
+}
+
+// ListMonitors returns all monitors with their latest run, oldest first
+func (a *HTTPAgent) ListMonitors() []models.Monitor {
+	a.monitors.mu.Lock()
+	defer a.monitors.mu.Unlock()
+
+	monitors := make([]models.Monitor, 0, len(a.monitors.monitors))
+	for _, entry := range a.monitors.monitors {
+		monitors = append(monitors, a.monitors.snapshot(entry, false))
+	}
+	sort.Slice(monitors, func(i, j int) bool { return monitors[i].CreatedAt.Before(monitors[j].CreatedAt) })
+	return monitors
+}
+
+// GetMonitor returns a monitor with its recent runs
+func (a *HTTPAgent) GetMonitor(id string) (*models.Monitor, error) {
+	a.monitors.mu.Lock()
+	defer a.monitors.mu.Unlock()
+
+	entry, ok := a.monitors.monitors[id]
+	if !ok {
+		return nil, ErrMonitorNotFound
+	}
+	monitor := a.monitors.snapshot(entry, true)
+	return &monitor, nil
+}
+
+// DeleteMonitor unschedules and removes a monitor
+func (a *HTTPAgent) DeleteMonitor(id string) error {
+	a.monitors.mu.Lock()
+	defer a.monitors.mu.Unlock()
+
+	entry, ok := a.monitors.monitors[id]
+	if !ok {
+		return ErrMonitorNotFound
+	}
+	a.monitors.cron.Remove(entry.entryID)
+	delete(a.monitors.monitors, id)
+	return nil
+}
+
+// RunMonitor executes a monitor now, records the run and sends alerts when it starts
+// failing or recovers
+func (a *HTTPAgent) RunMonitor(ctx context.Context, id string) (*models.MonitorRun, error) {
+	a.monitors.mu.Lock()
+	entry, ok := a.monitors.monitors[id]
+	if !ok {
+		a.monitors.mu.Unlock()
+		return nil, ErrMonitorNotFound
+	}
+	monitor := entry.monitor
+	a.monitors.mu.Unlock()
+
+	reqConfig := monitor.Request
+	reqConfig.Headers = make(map[string]string, len(monitor.Request.Headers))
+	for key, value := range monitor.Request.Headers {
+		reqConfig.Headers[key] = value
+	}
+	if monitor.Request.GraphQL != nil {
+		graphQL := *monitor.Request.GraphQL
+		reqConfig.GraphQL = &graphQL
+	}
+	reqConfig.Prompt = ""
+	reqConfig.OpenAPI = nil
+	reqConfig.Investigate = false
+
+	run := models.MonitorRun{Time: time.Now().UTC()}
+	response, err := a.executeRequest(ctx, &reqConfig)
+	if errors.Is(err, ErrBusy) {
+		// Nothing was sent, so the run is skipped rather than recorded as failing
+		return nil, err
+	}
+	if err != nil {
+		run.Error = err.Error()
+	} else {
+		run.StatusCode = response.StatusCode
+		run.Latency = response.Duration.Milliseconds()
+		run.Assertions = evaluateAssertions(reqConfig.Assertions, response)
+	}
+
+	result := &models.AnalysisResult{
+		Request:    &reqConfig,
+		Response:   response,
+		Error:      run.Error,
+		Assertions: run.Assertions,
+	}
+	if response != nil {
+		result.RequestDuration = FormatDuration(response.Duration)
+	}
+	a.recordHistory(ctx, result)
+	run.HistoryID = result.HistoryID
+
+	// Compare with the last healthy run and update the state
+	a.monitors.mu.Lock()
+	entry, ok = a.monitors.monitors[id]
+	if !ok {
+		a.monitors.mu.Unlock()
+		return nil, ErrMonitorNotFound
+	}
+	if response != nil {
+		run.Regressions = detectRegressions(&entry.monitor, &entry.baseline, response, run.Assertions)
+	}
+	run.Healthy = run.Error == "" && len(run.Regressions) == 0
+
+	previous := entry.monitor.State
+	if run.Healthy {
+		entry.monitor.State = monitorHealthy
+		entry.baseline.update(response)
+	} else {
+		entry.monitor.State = monitorFailing
+	}
+	monitor = entry.monitor
+	a.monitors.mu.Unlock()
+
+	// Alert only on transitions so a long outage does not flood the destinations
+	switch {
+	case !run.Healthy && previous != monitorFailing:
+		run.Summary = a.incidentSummary(ctx, &monitor, &reqConfig, response, &run)
+		run.AlertErrors = a.sendAlerts(ctx, &monitor, &run, false)
+	case run.Healthy && previous == monitorFailing:
+		run.AlertErrors = a.sendAlerts(ctx, &monitor, &run, true)
+	}
+
+	a.monitors.mu.Lock()
+	if entry, ok := a.monitors.monitors[id]; ok {
+		entry.runs = append([]models.MonitorRun{run}, entry.runs...)
+		if len(entry.runs) > a.monitors.maxRuns {
+			entry.runs = entry.runs[:a.monitors.maxRuns]
+		}
+	}
+	a.monitors.mu.Unlock()
+
+	return &run, nil
+}
+
+// executeRequest prepares and sends a stored request without analysis, masking secrets afterwards
+func (a *HTTPAgent) executeRequest(ctx context.Context, reqConfig *models.RequestConfig) (*models.Response, error) {
+	if reqConfig.GraphQL != nil {
+		if err := prepareGraphQLRequest(reqConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	var secrets map[string]string
+	if reqConfig.Environment != "" {
+		var err error
+		if secrets, err = a.applyEnvironment(reqConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := a.httpClient.MakeRequest(ctx, reqConfig)
+	maskRequest(reqConfig, secrets)
+	maskResponse(response, secrets)
+	if errors.Is(err, ErrBusy) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errors.New(maskSecrets(err.Error(), secrets))
+	}
+	return response, nil
+}
+
+// detectRegressions compares a response with the monitor expectations and its baseline
+func detectRegressions(monitor *models.Monitor, baseline *monitorBaseline, response *models.Response, assertions []models.AssertionResult) []string {
+	var regressions []string
+
+	expected := monitor.ExpectStatus
+	if expected == 0 {
+		expected = baseline.status
+	}
+	switch {
+	case expected != 0 && response.StatusCode != expected:
+		regressions = append(regressions, fmt.Sprintf("status %d, expected %d", response.StatusCode, expected))
+	case expected == 0 && response.StatusCode >= 400:
+		regressions = append(regressions, fmt.Sprintf("status %s", response.Status))
+	}
+
+	latency := response.Duration.Milliseconds()
+	if monitor.MaxLatency > 0 {
+		if latency > int64(monitor.MaxLatency) {
+			regressions = append(regressions, fmt.Sprintf("latency %dms exceeds %dms", latency, monitor.MaxLatency))
+		}
+	} else if len(baseline.latencies) >= 3 {
+		var total int64
+		for _, l := range baseline.latencies {
+			total += l
+		}
+		average := total / int64(len(baseline.latencies))
+		if latency > average*latencyRegressionFactor && latency-average >= minLatencyRegression {
+			regressions = append(regressions, fmt.Sprintf("latency %dms is over %dx the recent average of %dms", latency, latencyRegressionFactor, average))
+		}
+	}
+
+	if baseline.shape != nil {
+		if change := shapeChanges(baseline.shape, jsonShape(response.Body)); change != "" {
+			regressions = append(regressions, change)
+		}
+	}
+
+	for _, assertion := range assertions {
+		if !assertion.Passed {
+			regressions = append(regressions, fmt.Sprintf("assertion failed: %s", strings.TrimSpace(describeAssertions([]models.AssertionResult{assertion}))))
+		}
+	}
+
+	return regressions
+}
+
+// update records a healthy response as the new baseline
+func (b *monitorBaseline) update(response *models.Response) {
+	if b.status == 0 {
+		b.status = response.StatusCode
+	}
+	b.shape = jsonShape(response.Body)
+	b.latencies = append(b.latencies, response.Duration.Milliseconds())
+	if len(b.latencies) > latencyBaselineRuns {
+		b.latencies = b.latencies[len(b.latencies)-latencyBaselineRuns:]
+	}
+}
+
+// jsonShape maps the paths of a JSON body to their value types; nil when the body is not JSON.
+// Arrays are described by their first element.
+func jsonShape(body string) map[string]string {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil
+	}
+
+	shape := make(map[string]string)
+	var walk func(path string, value interface{})
+	walk = func(path string, value interface{}) {
+		if len(shape) >= maxShapePaths {
+			return
+		}
+		switch node := value.(type) {
+		case map[string]interface{}:
+			shape[path] = "object"
+			for key, child := range node {
+				walk(path+"."+key, child)
+			}
+		case []interface{}:
+			shape[path] = "array"
+			if len(node) > 0 {
+				walk(path+"[0]", node[0])
+			}
+		case string:
+			shape[path] = "string"
+		case float64:
+			shape[path] = "number"
+		case bool:
+			shape[path] = "boolean"
+		default:
+			shape[path] = "null"
+		}
+	}
+	walk("$", doc)
+	return shape
+}
+
+// shapeChanges describes fields that disappeared or changed type since the baseline.
+// New fields and values that are or were null are not reported.
+func shapeChanges(baseline, current map[string]string) string {
+	if current == nil {
+		return "response body is no longer JSON"
+	}
+
+	var changes []string
+	for path, kind := range baseline {
+		actual, ok := current[path]
+		switch {
+		case !ok:
+			// Only report the top-most missing field
+			parent := path[:strings.LastIndexAny(path, ".[")]
+			if _, parentPresent := current[parent]; parentPresent || parent == "$" {
+				changes = append(changes, "missing "+path)
+			}
+		case actual != kind && actual != "null" && kind != "null":
+			changes = append(changes, fmt.Sprintf("%s changed from %s to %s", path, kind, actual))
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	sort.Strings(changes)
+	if len(changes) > maxReportedShapeChanges {
+		changes = append(changes[:maxReportedShapeChanges], fmt.Sprintf("%d more", len(changes)-maxReportedShapeChanges))
+	}
+	return "response structure changed: " + strings.Join(changes, "; ")
+}
+
+// incidentSummary asks the LLM for a short incident report to attach to the alert
+func (a *HTTPAgent) incidentSummary(ctx context.Context, monitor *models.Monitor, reqConfig *models.RequestConfig, response *models.Response, run *models.MonitorRun) string {
+	problems := run.Regressions
+	if run.Error != "" {
+		problems = append([]string{"request failed: " + run.Error}, problems...)
+	}
+	question := fmt.Sprintf("This request is monitored on the schedule %q and has just started failing. Problems detected:\n- %s\nWrite a short incident summary for the on-call engineer: what is broken, the likely cause and what to check first.",
+		monitor.Schedule, strings.Join(problems, "\n- "))
+
+	var messages []models.ChatMessage
+	if response != nil {
+		var err error
+		if messages, err = a.prompts.AnalysisMessages(reqConfig.Profile, reqConfig, response, question); err != nil {
+			return fmt.Sprintf("Summary unavailable: %v", err)
+		}
+	} else {
+		messages = []models.ChatMessage{{
+			Role:    "user",
+			Content: fmt.Sprintf("Monitored request: %s %s\n\n%s", reqConfig.Method, reqConfig.URL, question),
+		}}
+	}
+
+	summary, err := a.chat(ctx, reqConfig.Profile, messages)
+	if err != nil {
+		return fmt.Sprintf("Summary unavailable: %v", err)
+	}
+	return summary
+}
@@ -0,0 +1,544 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"go.yaml.in/yaml/v3"
+)
+
+// ErrSpecNotFound is returned when an OpenAPI spec or operation is not loaded
+var ErrSpecNotFound = errors.New("OpenAPI spec or operation not found")
+
+// maxLoadedSpecs limits how many OpenAPI specs are kept in memory
+const maxLoadedSpecs = 20
+
+// maxSchemaDepth limits $ref resolution and example generation for recursive schemas
+const maxSchemaDepth = 6
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// SpecStore keeps loaded OpenAPI specs in memory
+type SpecStore struct {
+	mu    sync.RWMutex
+	specs map[string]*models.OpenAPISpec
+}
+
+// NewSpecStore creates an empty spec store
+func NewSpecStore() *SpecStore {
+	return &SpecStore{specs: make(map[string]*models.OpenAPISpec)}
+}
+
+// LoadOpenAPISpec parses an OpenAPI/Swagger document, fetching it first when a URL is given
+func (a *HTTPAgent) LoadOpenAPISpec(ctx context.Context, loadReq *models.OpenAPILoadRequest) (*models.OpenAPISpec, error) {
+	content := loadReq.Spec
+	if content == "" {
+		if loadReq.URL == "" {
+			return nil, fmt.Errorf("either url or spec is required")
+		}
+
+		// Fetch through the regular client so SSRF protections apply
+		response, err := a.httpClient.MakeRequest(ctx, &models.RequestConfig{
+			URL:     loadReq.URL,
+			Method:  "GET",
+			Headers: map[string]string{"Accept": "application/json, application/yaml"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch spec: %w", err)
+		}
+		if response.StatusCode != 200 {
+			return nil, fmt.Errorf("failed to fetch spec: server returned %s", response.Status)
+		}
+		content = response.Body
+	}
+
+	spec, err := parseOpenAPISpec(content, loadReq.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+	spec.ID = id
+	spec.LoadedAt = time.Now()
+
+	a.specs.mu.Lock()
+	defer a.specs.mu.Unlock()
+	if len(a.specs.specs) >= maxLoadedSpecs {
+		var oldestID string
+		for specID, s := range a.specs.specs {
+			if oldestID == "" || s.LoadedAt.Before(a.specs.specs[oldestID].LoadedAt) {
+				oldestID = specID
+			}
+		}
+		delete(a.specs.specs, oldestID)
+	}
+	a.specs.specs[id] = spec
+
+	return spec, nil
+}
+
+// ListOpenAPISpecs returns all loaded specs without their operations
+func (a *HTTPAgent) ListOpenAPISpecs() []models.OpenAPISpec {
+	a.specs.mu.RLock()
+	defer a.specs.mu.RUnlock()
+
+	specs := make([]models.OpenAPISpec, 0, len(a.specs.specs))
+	for _, spec := range a.specs.specs {
+		summary := *spec
+		summary.Operations = nil
+		specs = append(specs, summary)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].LoadedAt.Before(specs[j].LoadedAt) })
+	return specs
+}
+
+// GetOpenAPISpec returns a loaded spec with its operations
+func (a *HTTPAgent) GetOpenAPISpec(id string) (*models.OpenAPISpec, error) {
+	a.specs.mu.RLock()
+	defer a.specs.mu.RUnlock()
+
+	spec, ok := a.specs.specs[id]
+	if !ok {
+		return nil, ErrSpecNotFound
+	}
+	return spec, nil
+}
+
+// DeleteOpenAPISpec unloads a spec
+func (a *HTTPAgent) DeleteOpenAPISpec(id string) error {
+	a.specs.mu.Lock()
+	defer a.specs.mu.Unlock()
+
+	if _, ok := a.specs.specs[id]; !ok {
+		return ErrSpecNotFound
+	}
+	delete(a.specs.specs, id)
+	return nil
+}
+
+// resolveOpenAPIReference fills in the documented response schemas for a request
+func (a *HTTPAgent) resolveOpenAPIReference(ref *models.OpenAPIReference) error {
+	spec, err := a.GetOpenAPISpec(ref.SpecID)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range spec.Operations {
+		if op.ID == ref.OperationID {
+			ref.ResponseSchemas = op.ResponseSchemas
+			return nil
+		}
+	}
+	return ErrSpecNotFound
+}
+
+// parseOpenAPISpec parses a JSON or YAML OpenAPI 3.x / Swagger 2.0 document
+func parseOpenAPISpec(content, sourceURL string) (*models.OpenAPISpec, error) {
+	var raw interface{}
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") {
+		if err := json.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, fmt.Errorf("invalid JSON spec: %w", err)
+		}
+	} else {
+		if err := yaml.Unmarshal([]byte(trimmed), &raw); err != nil {
+			return nil, fmt.Errorf("invalid YAML spec: %w", err)
+		}
+		raw = normalizeYAML(raw)
+	}
+
+	doc, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("spec must be a JSON or YAML object")
+	}
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil, fmt.Errorf("document is not an OpenAPI or Swagger spec")
+	}
+
+	info := asMap(doc["info"])
+	spec := &models.OpenAPISpec{
+		Title:   asString(info["title"]),
+		Version: asString(info["version"]),
+		Source:  sourceURL,
+		BaseURL: specBaseURL(doc, sourceURL),
+	}
+
+	paths := asMap(doc["paths"])
+	pathNames := make([]string, 0, len(paths))
+	for path := range paths {
+		pathNames = append(pathNames, path)
+	}
+	sort.Strings(pathNames)
+
+	for _, path := range pathNames {
+		pathItem := asMap(resolveRef(doc, paths[path], 0))
+		for _, method := range openAPIMethods {
+			operation := asMap(pathItem[method])
+			if operation == nil {
+				continue
+			}
+			spec.Operations = append(spec.Operations, buildOperation(doc, spec.BaseURL, path, method, pathItem, operation))
+		}
+	}
+
+	return spec, nil
+}
+
+// buildOperation extracts an operation with a pre-filled example request
+func buildOperation(doc map[string]interface{}, baseURL, path, method string, pathItem, operation map[string]interface{}) models.OpenAPIOperation {
+	op := models.OpenAPIOperation{
+		ID:      asString(operation["operationId"]),
+		Method:  strings.ToUpper(method),
+		Path:    path,
+		Summary: asString(operation["summary"]),
+	}
+	if op.ID == "" {
+		op.ID = op.Method + " " + path
+	}
+	for _, tag := range asSlice(operation["tags"]) {
+		op.Tags = append(op.Tags, asString(tag))
+	}
+
+	example := &models.RequestConfig{
+		Method:  op.Method,
+		Headers: map[string]string{},
+	}
+
+	// Path-level parameters apply to every operation, operation-level ones override them
+	resolvedPath := path
+	query := url.Values{}
+	params := append(append([]interface{}{}, asSlice(pathItem["parameters"])...), asSlice(operation["parameters"])...)
+	for _, p := range params {
+		param := asMap(resolveRef(doc, p, 0))
+		name := asString(param["name"])
+		in := asString(param["in"])
+
+		if in == "body" {
+			// Swagger 2.0 body parameter
+			body := exampleFromSchema(doc, param["schema"], 0)
+			if encoded, err := json.MarshalIndent(body, "", "  "); err == nil {
+				example.Body = string(encoded)
+				example.Headers["Content-Type"] = "application/json"
+			}
+			continue
+		}
+
+		value := param["example"]
+		if value == nil {
+			schema := param["schema"]
+			if schema == nil {
+				schema = param // Swagger 2.0 keeps the type on the parameter itself
+			}
+			value = exampleFromSchema(doc, schema, 0)
+		}
+		required, _ := param["required"].(bool)
+
+		switch in {
+		case "path":
+			resolvedPath = strings.ReplaceAll(resolvedPath, "{"+name+"}", url.PathEscape(fmt.Sprint(value)))
+		case "query":
+			if required || param["example"] != nil {
+				query.Set(name, fmt.Sprint(value))
+			}
+		case "header":
+			if required || param["example"] != nil {
+				example.Headers[name] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	// OpenAPI 3.x request body, preferring JSON content
+	if requestBody := asMap(resolveRef(doc, operation["requestBody"], 0)); requestBody != nil {
+		content := asMap(requestBody["content"])
+		mediaTypes := make([]string, 0, len(content))
+		for mediaType := range content {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+		sort.Slice(mediaTypes, func(i, j int) bool {
+			return strings.Contains(mediaTypes[i], "json") && !strings.Contains(mediaTypes[j], "json")
+		})
+		if len(mediaTypes) > 0 {
+			media := asMap(content[mediaTypes[0]])
+			body := media["example"]
+			if body == nil {
+				body = exampleFromSchema(doc, media["schema"], 0)
+			}
+			if text, ok := body.(string); ok {
+				example.Body = text
+			} else if encoded, err := json.MarshalIndent(body, "", "  "); err == nil {
+				example.Body = string(encoded)
+			}
+			example.Headers["Content-Type"] = mediaTypes[0]
+		}
+	}
+
+	example.URL = strings.TrimSuffix(baseURL, "/") + resolvedPath
+	if len(query) > 0 {
+		example.URL += "?" + query.Encode()
+	}
+	op.Example = example
+
+	// Documented response schemas, with $refs inlined for the LLM
+	responses := asMap(operation["responses"])
+	if len(responses) > 0 {
+		op.ResponseSchemas = make(map[string]interface{})
+	}
+	for status, r := range responses {
+		response := asMap(resolveRef(doc, r, 0))
+		var schema interface{}
+		if content := asMap(response["content"]); content != nil {
+			for mediaType, media := range content {
+				if schema == nil || strings.Contains(mediaType, "json") {
+					schema = asMap(media)["schema"]
+				}
+			}
+		} else {
+			schema = response["schema"] // Swagger 2.0
+		}
+
+		entry := map[string]interface{}{"description": asString(response["description"])}
+		if schema != nil {
+			entry["schema"] = inlineRefs(doc, schema, 0)
+		}
+		op.ResponseSchemas[status] = entry
+	}
+
+	return op
+}
+
+// specBaseURL determines the API base URL from servers (3.x) or host/basePath (2.0)
+func specBaseURL(doc map[string]interface{}, sourceURL string) string {
+	source, _ := url.Parse(sourceURL)
+
+	if servers := asSlice(doc["servers"]); len(servers) > 0 {
+		server := asMap(servers[0])
+		serverURL := asString(server["url"])
+		for name, v := range asMap(server["variables"]) {
+			serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", asString(asMap(v)["default"]))
+		}
+		if source != nil && !strings.Contains(serverURL, "://") {
+			if ref, err := url.Parse(serverURL); err == nil {
+				serverURL = source.ResolveReference(ref).String()
+			}
+		}
+		return serverURL
+	}
+
+	scheme := "https"
+	if schemes := asSlice(doc["schemes"]); len(schemes) > 0 {
+		scheme = asString(schemes[0])
+	} else if source != nil && source.Scheme != "" {
+		scheme = source.Scheme
+	}
+	host := asString(doc["host"])
+	if host == "" && source != nil {
+		host = source.Host
+	}
+	if host == "" {
+		return asString(doc["basePath"])
+	}
+	return scheme + "://" + host + asString(doc["basePath"])
+}
+
+// exampleFromSchema synthesizes an example value for a JSON schema
+func exampleFromSchema(doc map[string]interface{}, schemaValue interface{}, depth int) interface{} {
+	return schemaExample(doc, schemaValue, depth, map[string]bool{})
+}
+
+// schemaExample builds the example, skipping $refs already being expanded (recursive schemas)
+func schemaExample(doc map[string]interface{}, schemaValue interface{}, depth int, expanding map[string]bool) interface{} {
+	if depth > maxSchemaDepth {
+		return nil
+	}
+	if ref, ok := asMap(schemaValue)["$ref"].(string); ok {
+		if expanding[ref] {
+			return nil
+		}
+		expanding[ref] = true
+		defer delete(expanding, ref)
+	}
+	schema := asMap(resolveRef(doc, schemaValue, 0))
+	if schema == nil {
+		return nil
+	}
+
+	if example, ok := schema["example"]; ok {
+		return example
+	}
+	if def, ok := schema["default"]; ok {
+		return def
+	}
+	if enum := asSlice(schema["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if allOf := asSlice(schema["allOf"]); len(allOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, part := range allOf {
+			if obj, ok := schemaExample(doc, part, depth+1, expanding).(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options := asSlice(schema[key]); len(options) > 0 {
+			return schemaExample(doc, options[0], depth+1, expanding)
+		}
+	}
+
+	schemaType := asString(schema["type"])
+	if schemaType == "" && schema["properties"] != nil {
+		schemaType = "object"
+	}
+
+	switch schemaType {
+	case "object":
+		obj := map[string]interface{}{}
+		for name, prop := range asMap(schema["properties"]) {
+			if value := schemaExample(doc, prop, depth+1, expanding); value != nil {
+				obj[name] = value
+			}
+		}
+		return obj
+	case "array":
+		item := schemaExample(doc, schema["items"], depth+1, expanding)
+		if item == nil {
+			return []interface{}{}
+		}
+		return []interface{}{item}
+	case "integer":
+		return 1
+	case "number":
+		return 1.0
+	case "boolean":
+		return true
+	case "string":
+		switch asString(schema["format"]) {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// inlineRefs returns a copy of the value with local $refs replaced by their targets;
+// recursive references are left as $ref
+func inlineRefs(doc map[string]interface{}, value interface{}, depth int) interface{} {
+	return inlineRefsFrom(doc, value, depth, map[string]bool{})
+}
+
+func inlineRefsFrom(doc map[string]interface{}, value interface{}, depth int, expanding map[string]bool) interface{} {
+	if depth > maxSchemaDepth*4 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			if expanding[ref] {
+				return v
+			}
+			expanding[ref] = true
+			defer delete(expanding, ref)
+			return inlineRefsFrom(doc, resolveRef(doc, v, 0), depth+1, expanding)
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = inlineRefsFrom(doc, child, depth+1, expanding)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = inlineRefsFrom(doc, child, depth+1, expanding)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// resolveRef follows local JSON pointer references ("#/components/schemas/User")
+func resolveRef(doc map[string]interface{}, value interface{}, depth int) interface{} {
+	obj := asMap(value)
+	ref, ok := obj["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, "#/") || depth > maxSchemaDepth {
+		return value
+	}
+
+	var current interface{} = doc
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		current = asMap(current)[segment]
+		if current == nil {
+			return value
+		}
+	}
+	return resolveRef(doc, current, depth+1)
+}
+
+// normalizeYAML converts YAML maps with non-string keys (e.g. status codes) to string-keyed maps
+func normalizeYAML(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = normalizeYAML(child)
+		}
+		return v
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[fmt.Sprint(k)] = normalizeYAML(child)
+		}
+		return out
+	case []interface{}:
+		for i, child := range v {
+			v[i] = normalizeYAML(child)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+func asMap(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+func asSlice(value interface{}) []interface{} {
+	s, _ := value.([]interface{})
+	return s
+}
+
+func asString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
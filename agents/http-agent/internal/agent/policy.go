@@ -0,0 +1,152 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrPolicyDenied is returned when a target is refused by the configured target policy
+var ErrPolicyDenied = errors.New("blocked by target policy")
+
+// targetPolicy restricts the hosts and ports the agent may reach
+type targetPolicy struct {
+	allowedHosts   []string // Host names, *.domain wildcards or IP addresses; empty allows any host
+	deniedHosts    []string
+	deniedNetworks []*net.IPNet // Denied IP addresses and CIDR ranges, also checked after DNS resolution
+	allowedPorts   map[int]bool // Empty allows any port
+	deniedPorts    map[int]bool
+}
+
+// newTargetPolicy compiles the configured policy
+func newTargetPolicy(config *models.TargetPolicy) (*targetPolicy, error) {
+	policy := &targetPolicy{
+		allowedPorts: make(map[int]bool),
+		deniedPorts:  make(map[int]bool),
+	}
+
+	for _, entry := range config.AllowedHosts {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			policy.allowedHosts = append(policy.allowedHosts, entry)
+		}
+	}
+	for _, entry := range config.DeniedHosts {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		switch {
+		case entry == "":
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid denied_hosts range %q: %w", entry, err)
+			}
+			policy.deniedNetworks = append(policy.deniedNetworks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip)
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 32
+			}
+			policy.deniedNetworks = append(policy.deniedNetworks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		default:
+			policy.deniedHosts = append(policy.deniedHosts, entry)
+		}
+	}
+
+	for _, port := range config.AllowedPorts {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid allowed_ports entry %d", port)
+		}
+		policy.allowedPorts[port] = true
+	}
+	for _, port := range config.DeniedPorts {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid denied_ports entry %d", port)
+		}
+		policy.deniedPorts[port] = true
+	}
+
+	return policy, nil
+}
+
+// checkURL applies the host and port rules to a request URL
+func (p *targetPolicy) checkURL(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+
+	if ip := net.ParseIP(host); ip != nil {
+		if err := p.checkIP(host, ip); err != nil {
+			return err
+		}
+	} else if entry, ok := matchHost(host, p.deniedHosts); ok {
+		return fmt.Errorf("%w: host %s is denied (%s)", ErrPolicyDenied, host, entry)
+	}
+	if len(p.allowedHosts) > 0 {
+		if _, ok := matchHost(host, p.allowedHosts); !ok {
+			return fmt.Errorf("%w: host %s is not in the allowed hosts (%s)", ErrPolicyDenied, host, strings.Join(p.allowedHosts, ", "))
+		}
+	}
+
+	port, err := urlPort(u)
+	if err != nil {
+		return err
+	}
+	if p.deniedPorts[port] {
+		return fmt.Errorf("%w: port %d is denied", ErrPolicyDenied, port)
+	}
+	if len(p.allowedPorts) > 0 && !p.allowedPorts[port] {
+		return fmt.Errorf("%w: port %d is not in the allowed ports", ErrPolicyDenied, port)
+	}
+
+	return nil
+}
+
+// checkIP refuses addresses in the denied ranges; host names the target the address belongs to
+func (p *targetPolicy) checkIP(host string, ip net.IP) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range p.deniedNetworks {
+		if network.Contains(ip) {
+			if host == ip.String() {
+				return fmt.Errorf("%w: address %s is denied (%s)", ErrPolicyDenied, ip, network)
+			}
+			return fmt.Errorf("%w: %s resolves to denied address %s (%s)", ErrPolicyDenied, host, ip, network)
+		}
+	}
+	return nil
+}
+
+// matchHost returns the first entry matching a host: the host itself or a *.domain wildcard
+// covering its subdomains
+func matchHost(host string, entries []string) (string, bool) {
+	for _, entry := range entries {
+		if entry == host {
+			return entry, true
+		}
+		if suffix, ok := strings.CutPrefix(entry, "*."); ok && strings.HasSuffix(host, "."+suffix) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// urlPort returns the explicit port of a URL or the default port of its scheme
+func urlPort(u *url.URL) (int, error) {
+	if port := u.Port(); port != "" {
+		value, err := strconv.Atoi(port)
+		if err != nil || value < 1 || value > 65535 {
+			return 0, fmt.Errorf("invalid port %q", port)
+		}
+		return value, nil
+	}
+	switch u.Scheme {
+	case "https", "wss", "grpcs":
+		return 443, nil
+	default:
+		return 80, nil
+	}
+}
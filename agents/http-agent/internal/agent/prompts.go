@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrUnknownProfile is returned when a request selects an analysis profile that has no template
+var ErrUnknownProfile = errors.New("unknown analysis profile")
+
+// defaultProfile is used when a request does not select an analysis profile
+const defaultProfile = "default"
+
+//go:embed prompts/*.tmpl
+var promptsFS embed.FS
+
+// PromptTemplates renders the system and user prompts of each analysis profile.
+// A profile is defined by <name>.system.tmpl and optionally <name>.user.tmpl;
+// profiles without a user template use default.user.tmpl
+type PromptTemplates struct {
+	templates *template.Template
+}
+
+// promptData is passed to the user prompt templates
+type promptData struct {
+	Request      *models.RequestConfig
+	Response     *models.Response
+	Question     string
+	Duration     string
+	RequestBody  string // Truncated request body
+	ResponseBody string // Truncated response body
+	Details      string // Protocol-specific sections (gRPC, WebSocket, OpenAPI, GraphQL, assertions)
+}
+
+// LoadPromptTemplates parses the built-in templates and the *.tmpl files in dir, which
+// override built-in templates of the same name and can add new profiles
+func LoadPromptTemplates(dir string) (*PromptTemplates, error) {
+	templates, err := template.New("prompts").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).ParseFS(promptsFS, "prompts/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse built-in prompt templates: %w", err)
+	}
+
+	if dir != "" {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("failed to read prompt templates directory: %w", err)
+		}
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list prompt templates: %w", err)
+		}
+		if len(files) > 0 {
+			if templates, err = templates.ParseFiles(files...); err != nil {
+				return nil, fmt.Errorf("failed to parse prompt templates: %w", err)
+			}
+		}
+	}
+
+	return &PromptTemplates{templates: templates}, nil
+}
+
+// Profiles returns the names of the available analysis profiles
+func (p *PromptTemplates) Profiles() []string {
+	var profiles []string
+	for _, t := range p.templates.Templates() {
+		if name, ok := strings.CutSuffix(t.Name(), ".system.tmpl"); ok {
+			profiles = append(profiles, name)
+		}
+	}
+	sort.Strings(profiles)
+	return profiles
+}
+
+// Validate checks that a profile exists; an empty name selects the default profile
+func (p *PromptTemplates) Validate(profile string) error {
+	if p.templates.Lookup(profileName(profile)+".system.tmpl") == nil {
+		return fmt.Errorf("%w: %s (available: %s)", ErrUnknownProfile, profile, strings.Join(p.Profiles(), ", "))
+	}
+	return nil
+}
+
+// System renders the system prompt of a profile
+func (p *PromptTemplates) System(profile string) (string, error) {
+	if err := p.Validate(profile); err != nil {
+		return "", err
+	}
+	return p.render(profileName(profile)+".system.tmpl", nil)
+}
+
+// User renders the request/response analysis prompt of a profile
+func (p *PromptTemplates) User(profile string, request *models.RequestConfig, response *models.Response, question string) (string, error) {
+	if err := p.Validate(profile); err != nil {
+		return "", err
+	}
+
+	name := profileName(profile) + ".user.tmpl"
+	if p.templates.Lookup(name) == nil {
+		name = defaultProfile + ".user.tmpl"
+	}
+
+	// Cookie values are session credentials and never reach the LLM
+	redactedRequest := *request
+	if len(request.Headers) > 0 {
+		redactedRequest.Headers = make(map[string]string, len(request.Headers))
+		for key, value := range request.Headers {
+			redactedRequest.Headers[key] = redactCookieHeader(key, value)
+		}
+	}
+	redactedResponse := *response
+	redactedResponse.Headers = redactCookieHeaders(response.Headers)
+
+	return p.render(name, &promptData{
+		Request:      &redactedRequest,
+		Response:     &redactedResponse,
+		Question:     userQuestion(question),
+		Duration:     FormatDuration(response.Duration),
+		RequestBody:  truncatePrompt(request.Body, 500),
+		ResponseBody: truncatePrompt(response.Body, 1000),
+		Details:      promptDetails(request, response),
+	})
+}
+
+// AnalysisMessages wraps a request/response analysis as a one-message conversation
+func (p *PromptTemplates) AnalysisMessages(profile string, request *models.RequestConfig, response *models.Response, question string) ([]models.ChatMessage, error) {
+	prompt, err := p.User(profile, request, response, question)
+	if err != nil {
+		return nil, err
+	}
+	return []models.ChatMessage{{Role: "user", Content: prompt}}, nil
+}
+
+// render executes a named template and trims surrounding whitespace
+func (p *PromptTemplates) render(name string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := p.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render prompt template %s: %w", name, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// profileName maps an empty profile to the default one
+func profileName(profile string) string {
+	if profile == "" {
+		return defaultProfile
+	}
+	return profile
+}
+
+// truncatePrompt shortens long text included in a prompt
+func truncatePrompt(text string, limit int) string {
+	if len(text) > limit {
+		return text[:limit] + "... (truncated)"
+	}
+	return text
+}
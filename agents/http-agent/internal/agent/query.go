@@ -0,0 +1,48 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrInvalidQuery is returned when a query cannot be run against a stored response
+var ErrInvalidQuery = errors.New("invalid query")
+
+// maxQueryExpressions limits the expressions evaluated per query
+const maxQueryExpressions = 20
+
+// QueryHistory runs JSONPath or jq-style expressions against the body of a stored response.
+// Expression errors are reported per expression; the body must be JSON
+func (a *HTTPAgent) QueryHistory(ctx context.Context, id int64, query *models.QueryRequest) (*models.QueryResponse, error) {
+	if len(query.Expressions) > maxQueryExpressions {
+		return nil, fmt.Errorf("%w: at most %d expressions can be evaluated per query", ErrInvalidQuery, maxQueryExpressions)
+	}
+
+	result, err := a.responseEntry(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal([]byte(result.Response.Body), &doc); err != nil {
+		return nil, fmt.Errorf("%w: entry %d response body is not JSON", ErrInvalidQuery, id)
+	}
+
+	response := &models.QueryResponse{HistoryID: id, Results: make([]models.QueryResult, len(query.Expressions))}
+	for i, expression := range query.Expressions {
+		queryResult := models.QueryResult{Expression: expression, Values: []interface{}{}}
+		values, _, err := queryJSONPath(doc, expression)
+		if err != nil {
+			queryResult.Error = err.Error()
+		} else if values != nil {
+			queryResult.Values = values
+		}
+		response.Results[i] = queryResult
+	}
+
+	return response, nil
+}
@@ -0,0 +1,434 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	// reachabilityProbes is the number of TCP connects and ICMP echoes sent to the target
+	reachabilityProbes = 3
+	// probeTimeout bounds a single TCP connect or ICMP echo
+	probeTimeout = 2 * time.Second
+	// hopTimeout bounds the wait for a router's answer during a traceroute
+	hopTimeout = time.Second
+	// defaultMaxHops and maxHops limit how far a traceroute goes
+	defaultMaxHops = 30
+	maxHops        = 64
+)
+
+// probeReachability checks the target host and port over TCP and ICMP, and traces the route
+// when asked. The target policy applies to the probes like it does to requests
+func (c *HTTPClient) probeReachability(ctx context.Context, rawURL string, options *models.ReachabilityOptions) *models.ReachabilityReport {
+	report := &models.ReachabilityReport{}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		report.Error = fmt.Sprintf("Failed to parse URL: %v", err)
+		return report
+	}
+	report.Host = parsedURL.Hostname()
+	report.Port = targetPort(parsedURL)
+
+	if err := c.validateURL(rawURL); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+
+	// Check every resolved address like the dialer does, then probe the first one
+	ips := []net.IP{net.ParseIP(report.Host)}
+	if ips[0] == nil {
+		resolveCtx, cancel := context.WithTimeout(ctx, dnsQueryTimeout)
+		ips, err = net.DefaultResolver.LookupIP(resolveCtx, "ip", report.Host)
+		cancel()
+		if err != nil {
+			report.Error = fmt.Sprintf("DNS lookup failed: %v", err)
+			return report
+		}
+	}
+	for _, ip := range ips {
+		if c.blockPrivateIPs && isBlockedIP(ip) {
+			err = fmt.Errorf("access to private IP addresses is blocked (%s resolves to %s)", report.Host, ip)
+		} else {
+			err = c.policy.checkIP(report.Host, ip)
+		}
+		if err != nil {
+			report.Error = err.Error()
+			c.auditProbe(ctx, "tcp", rawURL, 0, report.Error, time.Now())
+			return report
+		}
+	}
+	ip := ips[0]
+	report.Address = ip.String()
+
+	// The probes count as one outbound request against the concurrency cap
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	defer release()
+
+	tcpTarget := net.JoinHostPort(report.Address, strconv.Itoa(report.Port))
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		report.TCP = c.probeTCP(ctx, tcpTarget)
+		c.auditProbe(ctx, "tcp", "tcp://"+tcpTarget, report.TCP.Sent, probeError(report.TCP), start)
+	}()
+	go func() {
+		defer wg.Done()
+		start := time.Now()
+		report.ICMP = probeICMP(ctx, ip)
+		c.auditProbe(ctx, "icmp", "icmp://"+report.Address, report.ICMP.Sent, probeError(report.ICMP), start)
+	}()
+	wg.Wait()
+
+	if options.Traceroute {
+		hops := options.MaxHops
+		if hops <= 0 {
+			hops = defaultMaxHops
+		}
+		start := time.Now()
+		report.Traceroute, err = traceroute(ctx, ip, min(hops, maxHops))
+		if err != nil {
+			report.TracerouteError = err.Error()
+		}
+		c.auditProbe(ctx, "traceroute", "icmp://"+report.Address, len(report.Traceroute), report.TracerouteError, start)
+	}
+	return report
+}
+
+// auditProbe records reachability probes, which do not go through the audited transport
+func (c *HTTPClient) auditProbe(ctx context.Context, protocol, target string, sent int, errMessage string, start time.Time) {
+	c.audit.Record(ctx, models.AuditEntry{
+		Timestamp: start.UTC(),
+		Protocol:  protocol,
+		Method:    "PROBE",
+		URL:       target,
+		Error:     errMessage,
+		Duration:  time.Since(start).Milliseconds(),
+		Requests:  sent,
+	})
+}
+
+// probeError returns the error of a probe that got no answer at all
+func probeError(result *models.ProbeResult) string {
+	if result.Received > 0 {
+		return ""
+	}
+	return result.Error
+}
+
+// targetPort returns the URL's port, or the default port of its scheme
+func targetPort(parsedURL *url.URL) int {
+	if port, err := strconv.Atoi(parsedURL.Port()); err == nil {
+		return port
+	}
+	switch parsedURL.Scheme {
+	case "https", "wss", "grpcs":
+		return 443
+	default:
+		return 80
+	}
+}
+
+// probeTCP measures how long connecting to the target port takes
+func (c *HTTPClient) probeTCP(ctx context.Context, address string) *models.ProbeResult {
+	var rtts []time.Duration
+	var lastErr error
+	for range reachabilityProbes {
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		start := time.Now()
+		conn, err := c.dialContext(probeCtx, "tcp", address)
+		rtt := time.Since(start)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		rtts = append(rtts, rtt)
+	}
+	return probeResult(rtts, lastErr)
+}
+
+// icmpConn is an ICMP socket with what is needed to build and read its messages
+type icmpConn struct {
+	*icmp.PacketConn
+	ip         net.IP
+	protocol   int // IANA protocol number used to parse answers
+	echo       icmp.Type
+	echoReply  icmp.Type
+	privileged bool // Raw socket; unprivileged sockets do not see other hosts' errors
+}
+
+// listenICMP opens a raw ICMP socket, falling back to an unprivileged one (Linux ping_group_range)
+func listenICMP(ip net.IP) (*icmpConn, error) {
+	conn := &icmpConn{ip: ip, protocol: 1, echo: ipv4.ICMPTypeEcho, echoReply: ipv4.ICMPTypeEchoReply}
+	raw, udp := "ip4:icmp", "udp4"
+	if ip.To4() == nil {
+		conn.protocol, conn.echo, conn.echoReply = 58, ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		raw, udp = "ip6:ipv6-icmp", "udp6"
+	}
+
+	var err error
+	if conn.PacketConn, err = icmp.ListenPacket(raw, ""); err == nil {
+		conn.privileged = true
+		return conn, nil
+	}
+	if conn.PacketConn, err = icmp.ListenPacket(udp, ""); err != nil {
+		return nil, fmt.Errorf("ICMP is not permitted (needs root, CAP_NET_RAW or ping_group_range): %w", err)
+	}
+	return conn, nil
+}
+
+// destination returns the target address in the form the socket expects
+func (c *icmpConn) destination() net.Addr {
+	if c.privileged {
+		return &net.IPAddr{IP: c.ip}
+	}
+	return &net.UDPAddr{IP: c.ip}
+}
+
+// setTTL limits how many routers an echo request may cross
+func (c *icmpConn) setTTL(ttl int) error {
+	if c.protocol == 1 {
+		return c.IPv4PacketConn().SetTTL(ttl)
+	}
+	return c.IPv6PacketConn().SetHopLimit(ttl)
+}
+
+// sendEcho sends an echo request; unprivileged sockets replace the ID with their own
+func (c *icmpConn) sendEcho(id, seq int) error {
+	message := icmp.Message{Type: c.echo, Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("http-agent")}}
+	packet, err := message.Marshal(nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.WriteTo(packet, c.destination())
+	return err
+}
+
+// awaitAnswer reads until the echo reply or the router error matching the request arrives,
+// returning who answered and whether it was the target
+func (c *icmpConn) awaitAnswer(ctx context.Context, id, seq int, timeout time.Duration) (string, bool, error) {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := c.SetReadDeadline(deadline); err != nil {
+		return "", false, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := c.ReadFrom(buf)
+		if err != nil {
+			return "", false, err
+		}
+		message, err := icmp.ParseMessage(c.protocol, buf[:n])
+		if err != nil {
+			continue
+		}
+		switch body := message.Body.(type) {
+		case *icmp.Echo:
+			if message.Type == c.echoReply && body.Seq == seq && (!c.privileged || body.ID == id) {
+				return addrIP(peer), true, nil
+			}
+		case *icmp.TimeExceeded:
+			if c.quotesEcho(body.Data, id, seq) {
+				return addrIP(peer), false, nil
+			}
+		case *icmp.DstUnreach:
+			if c.quotesEcho(body.Data, id, seq) {
+				return addrIP(peer), addrIP(peer) == c.ip.String(), nil
+			}
+		}
+	}
+}
+
+// quotesEcho reports whether an ICMP error quotes one of our echo requests
+func (c *icmpConn) quotesEcho(data []byte, id, seq int) bool {
+	headerLen := 40 // IPv6
+	if c.protocol == 1 {
+		if len(data) == 0 {
+			return false
+		}
+		headerLen = int(data[0]&0x0f) * 4
+	}
+	if len(data) < headerLen+8 {
+		return false
+	}
+	quoted := data[headerLen:]
+	return int(binary.BigEndian.Uint16(quoted[4:6])) == id && int(binary.BigEndian.Uint16(quoted[6:8])) == seq
+}
+
+// probeICMP pings the target
+func probeICMP(ctx context.Context, ip net.IP) *models.ProbeResult {
+	conn, err := listenICMP(ip)
+	if err != nil {
+		return &models.ProbeResult{Error: err.Error()}
+	}
+	defer conn.Close()
+
+	id := icmpID()
+	var rtts []time.Duration
+	var lastErr error
+	for seq := 1; seq <= reachabilityProbes; seq++ {
+		start := time.Now()
+		if err := conn.sendEcho(id, seq); err != nil {
+			lastErr = err
+			continue
+		}
+		if _, reached, err := conn.awaitAnswer(ctx, id, seq, probeTimeout); err != nil {
+			lastErr = err
+		} else if reached {
+			rtts = append(rtts, time.Since(start))
+		}
+	}
+	return probeResult(rtts, lastErr)
+}
+
+// traceroute sends echo requests with growing TTLs and lists the routers that answer. Without
+// a raw socket only the target's answer is seen, so intermediate hops stay empty
+func traceroute(ctx context.Context, ip net.IP, hops int) ([]models.TracerouteHop, error) {
+	conn, err := listenICMP(ip)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	id := icmpID()
+	var route []models.TracerouteHop
+	for ttl := 1; ttl <= hops; ttl++ {
+		if ctx.Err() != nil {
+			return route, ctx.Err()
+		}
+		if err := conn.setTTL(ttl); err != nil {
+			return route, fmt.Errorf("failed to set TTL: %w", err)
+		}
+
+		hop := models.TracerouteHop{Hop: ttl}
+		start := time.Now()
+		if err := conn.sendEcho(id, ttl); err != nil {
+			return route, fmt.Errorf("failed to send probe: %w", err)
+		}
+		if address, reached, err := conn.awaitAnswer(ctx, id, ttl, hopTimeout); err == nil {
+			hop.Address = address
+			hop.RTT = FormatDuration(time.Since(start))
+			hop.Target = reached
+		}
+		route = append(route, hop)
+		if hop.Target {
+			break
+		}
+	}
+
+	if !conn.privileged {
+		return route, fmt.Errorf("intermediate hops are hidden without raw socket privileges (root or CAP_NET_RAW)")
+	}
+	return route, nil
+}
+
+// probeResult summarizes the round trip times of the answered probes
+func probeResult(rtts []time.Duration, lastErr error) *models.ProbeResult {
+	result := &models.ProbeResult{
+		Sent:        reachabilityProbes,
+		Received:    len(rtts),
+		LossPercent: float64(reachabilityProbes-len(rtts)) * 100 / reachabilityProbes,
+	}
+	if lastErr != nil {
+		result.Error = lastErr.Error()
+	}
+	if len(rtts) == 0 {
+		return result
+	}
+
+	minRTT, maxRTT, total := rtts[0], rtts[0], time.Duration(0)
+	for _, rtt := range rtts {
+		minRTT, maxRTT, total = min(minRTT, rtt), max(maxRTT, rtt), total+rtt
+	}
+	result.MinRTT = FormatDuration(minRTT)
+	result.AvgRTT = FormatDuration(total / time.Duration(len(rtts)))
+	result.MaxRTT = FormatDuration(maxRTT)
+	return result
+}
+
+// describeReachability renders a reachability report for the LLM
+func describeReachability(report *models.ReachabilityReport) string {
+	if report.Error != "" {
+		return fmt.Sprintf("- Not probed: %s\n", report.Error)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- Target: %s (%s) port %d\n", report.Host, report.Address, report.Port))
+	for _, probe := range []struct {
+		name   string
+		result *models.ProbeResult
+	}{{"TCP connect", report.TCP}, {"ICMP ping", report.ICMP}} {
+		if probe.result == nil {
+			continue
+		}
+		if probe.result.Received == 0 && probe.result.Sent == 0 {
+			sb.WriteString(fmt.Sprintf("- %s: unavailable (%s)\n", probe.name, probe.result.Error))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %d/%d answered (%.0f%% loss)", probe.name,
+			probe.result.Received, probe.result.Sent, probe.result.LossPercent))
+		if probe.result.Received > 0 {
+			sb.WriteString(fmt.Sprintf(", rtt min/avg/max %s/%s/%s", probe.result.MinRTT, probe.result.AvgRTT, probe.result.MaxRTT))
+		}
+		if probe.result.Error != "" {
+			sb.WriteString(fmt.Sprintf(", last error: %s", probe.result.Error))
+		}
+		sb.WriteString("\n")
+	}
+	if len(report.Traceroute) > 0 {
+		sb.WriteString("- Traceroute:\n")
+		for _, hop := range report.Traceroute {
+			if hop.Address == "" {
+				sb.WriteString(fmt.Sprintf("  %d. *\n", hop.Hop))
+			} else {
+				sb.WriteString(fmt.Sprintf("  %d. %s %s\n", hop.Hop, hop.Address, hop.RTT))
+			}
+		}
+	}
+	if report.TracerouteError != "" {
+		sb.WriteString(fmt.Sprintf("- Traceroute note: %s\n", report.TracerouteError))
+	}
+	return sb.String()
+}
+
+// addrIP returns the IP address of a socket peer
+func addrIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	default:
+		return addr.String()
+	}
+}
+
+// icmpID returns a random echo identifier, so concurrent probes ignore each other's answers
+func icmpID() int {
+	var b [2]byte
+	rand.Read(b[:])
+	return int(binary.BigEndian.Uint16(b[:]))
+}
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// BuildRequest asks the LLM to turn a natural-language description into a request
+// configuration. The request is only drafted, not executed, so the user can review it first.
+func (a *HTTPAgent) BuildRequest(ctx context.Context, description string) (*models.RequestConfig, error) {
+	reply, err := a.llmClient.Chat(ctx, buildRequestBuilderPrompt(), []models.ChatMessage{
+		{Role: "user", Content: description},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	var reqConfig models.RequestConfig
+	if err := json.Unmarshal([]byte(extractJSONObject(reply)), &reqConfig); err != nil {
+		return nil, fmt.Errorf("LLM returned an invalid request definition: %w", err)
+	}
+
+	reqConfig.Method = strings.ToUpper(strings.TrimSpace(reqConfig.Method))
+	if reqConfig.Method == "" {
+		reqConfig.Method = "GET"
+	}
+
+	reqConfig.URL = strings.TrimSpace(reqConfig.URL)
+	if reqConfig.URL == "" {
+		return nil, fmt.Errorf("could not determine a URL from the description")
+	}
+	if !strings.Contains(reqConfig.URL, "://") {
+		reqConfig.URL = "https://" + reqConfig.URL
+	}
+	parsedURL, err := url.Parse(reqConfig.URL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return nil, fmt.Errorf("LLM produced an invalid URL: %s", reqConfig.URL)
+	}
+
+	return &reqConfig, nil
+}
+
+// extractJSONObject returns the outermost JSON object in an LLM reply,
+// tolerating markdown code fences and surrounding prose
+func extractJSONObject(reply string) string {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end < start {
+		return reply
+	}
+	return reply[start : end+1]
+}
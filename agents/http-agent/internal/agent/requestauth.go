@@ -0,0 +1,286 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrInvalidAuth is returned when a request's auth block is incomplete or of an unknown type
+var ErrInvalidAuth = errors.New("invalid request auth")
+
+const (
+	// tokenExpiryMargin renews cached OAuth2 tokens this long before they expire
+	tokenExpiryMargin = 30 * time.Second
+	// defaultTokenLifetime is how long a token without expires_in is cached
+	defaultTokenLifetime = 5 * time.Minute
+	// maxTokenResponseSize limits the token endpoint response that is read
+	maxTokenResponseSize = 64 * 1024
+)
+
+// validateRequestAuth checks that an auth block has the fields its type needs
+func validateRequestAuth(auth *models.RequestAuth) error {
+	if auth == nil {
+		return nil
+	}
+
+	switch strings.ToLower(auth.Type) {
+	case "basic":
+		if auth.Username == "" {
+			return fmt.Errorf("%w: basic auth needs a username", ErrInvalidAuth)
+		}
+	case "bearer":
+		if auth.Token == "" {
+			return fmt.Errorf("%w: bearer auth needs a token", ErrInvalidAuth)
+		}
+	case "api_key":
+		if auth.Value == "" {
+			return fmt.Errorf("%w: api_key auth needs a value", ErrInvalidAuth)
+		}
+		if in := strings.ToLower(auth.In); in != "" && in != "header" && in != "query" {
+			return fmt.Errorf("%w: api_key auth is sent in a header or query, not %q", ErrInvalidAuth, auth.In)
+		}
+		if strings.EqualFold(auth.In, "query") && auth.Name == "" {
+			return fmt.Errorf("%w: api_key auth in the query needs a parameter name", ErrInvalidAuth)
+		}
+	case "oauth2":
+		if auth.TokenURL == "" || auth.ClientID == "" || auth.ClientSecret == "" {
+			return fmt.Errorf("%w: oauth2 auth needs token_url, client_id and client_secret", ErrInvalidAuth)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported type %q (use basic, bearer, api_key or oauth2)", ErrInvalidAuth, auth.Type)
+	}
+	return nil
+}
+
+// authenticate returns the request to send with its auth block turned into headers (or a query
+// parameter), and the credential values to mask in what comes back. The caller's request is
+// left untouched
+func (c *HTTPClient) authenticate(ctx context.Context, reqConfig *models.RequestConfig) (*models.RequestConfig, map[string]string, error) {
+	auth := reqConfig.Auth
+	if auth == nil {
+		return reqConfig, nil, nil
+	}
+	if err := validateRequestAuth(auth); err != nil {
+		return nil, nil, err
+	}
+
+	credentials := make(map[string]string)
+	addCredential := func(value string) {
+		if len(value) >= minMaskedSecretLength {
+			credentials[value] = secretMask
+		}
+	}
+
+	sent := *reqConfig
+	sent.Headers = make(map[string]string, len(reqConfig.Headers)+1)
+	for key, value := range reqConfig.Headers {
+		sent.Headers[key] = value
+	}
+
+	switch strings.ToLower(auth.Type) {
+	case "basic":
+		encoded := base64.StdEncoding.EncodeToString([]byte(auth.Username + ":" + auth.Password))
+		sent.Headers["Authorization"] = "Basic " + encoded
+		addCredential(encoded)
+		addCredential(auth.Password)
+	case "bearer":
+		sent.Headers["Authorization"] = "Bearer " + auth.Token
+		addCredential(auth.Token)
+	case "api_key":
+		name := auth.Name
+		if name == "" {
+			name = "X-API-Key"
+		}
+		addCredential(auth.Value)
+		if !strings.EqualFold(auth.In, "query") {
+			sent.Headers[name] = auth.Value
+			break
+		}
+		parsed, err := url.Parse(reqConfig.URL)
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed URL: %w", err)
+		}
+		query := parsed.Query()
+		query.Set(name, auth.Value)
+		parsed.RawQuery = query.Encode()
+		sent.URL = parsed.String()
+		addCredential(url.QueryEscape(auth.Value))
+	case "oauth2":
+		verifySSL := c.config.VerifySSL
+		if reqConfig.VerifySSL != nil {
+			verifySSL = *reqConfig.VerifySSL
+		}
+		token, err := c.oauth2Token(ctx, auth, verifySSL)
+		if err != nil {
+			return nil, nil, err
+		}
+		sent.Headers["Authorization"] = "Bearer " + token
+		addCredential(token)
+		addCredential(auth.ClientSecret)
+	}
+	return &sent, credentials, nil
+}
+
+// oauth2Token returns a cached access token or fetches one with the client credentials grant
+func (c *HTTPClient) oauth2Token(ctx context.Context, auth *models.RequestAuth, verifySSL bool) (string, error) {
+	key := tokenCacheKey(auth)
+	if token, ok := c.tokens.get(key, time.Now()); ok {
+		return token, nil
+	}
+
+	if err := c.validateURL(auth.TokenURL); err != nil {
+		return "", fmt.Errorf("invalid token URL: %w", err)
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	if auth.Audience != "" {
+		form.Set("audience", auth.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	resp, err := c.createCustomClient(verifySSL).Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch OAuth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read OAuth2 token response: %w", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.Unmarshal(body, &result)
+	if resp.StatusCode != http.StatusOK {
+		reason := strings.TrimSpace(result.Error + " " + result.ErrorDescription)
+		if reason == "" {
+			reason = "no error details"
+		}
+		return "", fmt.Errorf("OAuth2 token endpoint returned %s: %s", resp.Status, reason)
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("failed to decode OAuth2 token response: %w", decodeErr)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("OAuth2 token response has no access_token")
+	}
+	if result.TokenType != "" && !strings.EqualFold(result.TokenType, "Bearer") {
+		return "", fmt.Errorf("unsupported OAuth2 token type %q", result.TokenType)
+	}
+
+	lifetime := defaultTokenLifetime
+	if result.ExpiresIn > 0 {
+		lifetime = time.Duration(result.ExpiresIn)*time.Second - tokenExpiryMargin
+	}
+	if lifetime > 0 {
+		c.tokens.put(key, result.AccessToken, time.Now().Add(lifetime))
+	}
+	return result.AccessToken, nil
+}
+
+// tokenCacheKey identifies the token of a client, scope and audience without keeping the secret
+func tokenCacheKey(auth *models.RequestAuth) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		auth.TokenURL, auth.ClientID, auth.ClientSecret, strings.Join(auth.Scopes, " "), auth.Audience,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenCache keeps OAuth2 access tokens until shortly before they expire
+type tokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]cachedToken
+}
+
+// cachedToken is an access token with its renewal time
+type cachedToken struct {
+	token   string
+	expires time.Time
+}
+
+// newTokenCache creates an empty token cache
+func newTokenCache() *tokenCache {
+	return &tokenCache{tokens: make(map[string]cachedToken)}
+}
+
+// get returns a token that is still valid
+func (c *tokenCache) get(key string, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.tokens[key]
+	if !ok || !now.Before(cached.expires) {
+		return "", false
+	}
+	return cached.token, true
+}
+
+// put stores a token, dropping expired ones
+func (c *tokenCache) put(key, token string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, cached := range c.tokens {
+		if !now.Before(cached.expires) {
+			delete(c.tokens, k)
+		}
+	}
+	c.tokens[key] = cachedToken{token: token, expires: expires}
+}
+
+// redactAuth copies an auth block with its secrets replaced by the mask
+func redactAuth(auth *models.RequestAuth) *models.RequestAuth {
+	if auth == nil {
+		return nil
+	}
+	redacted := *auth
+	for _, secret := range []*string{&redacted.Password, &redacted.Token, &redacted.Value, &redacted.ClientSecret} {
+		if *secret != "" {
+			*secret = secretMask
+		}
+	}
+	return &redacted
+}
+
+// substituteAuth fills in placeholders in the auth block, copying it
+func substituteAuth(auth *models.RequestAuth, variables map[string]string) *models.RequestAuth {
+	if auth == nil {
+		return nil
+	}
+	substituted := *auth
+	for _, field := range []*string{&substituted.Username, &substituted.Password, &substituted.Token, &substituted.Value,
+		&substituted.TokenURL, &substituted.ClientID, &substituted.ClientSecret, &substituted.Audience} {
+		*field = substituteVariables(*field, variables)
+	}
+	return &substituted
+}
@@ -0,0 +1,229 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+const (
+	// minHSTSMaxAge is the shortest HSTS max-age (180 days) that is not reported as weak
+	minHSTSMaxAge = 15552000
+
+	// maxAuditedCookies limits the Set-Cookie headers checked per response
+	maxAuditedCookies = 20
+)
+
+// severityPenalty is deducted from the score for each failed check
+var severityPenalty = map[string]int{
+	"high":   20,
+	"medium": 10,
+	"low":    5,
+}
+
+// auditSecurityHeaders checks an HTTP(S) response for security headers and cookie flags;
+// it returns nil for other protocols
+func auditSecurityHeaders(rawURL string, response *models.Response) *models.SecurityReport {
+	if response == nil {
+		return nil
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return nil
+	}
+
+	headers := http.Header(response.Headers)
+	https := parsedURL.Scheme == "https"
+	// Framing and script policies matter most for documents rendered by browsers
+	documentSeverity := "low"
+	if strings.Contains(strings.ToLower(response.ContentType), "html") {
+		documentSeverity = "medium"
+	}
+
+	var findings []models.SecurityFinding
+	pass := func(check, value, message string) {
+		findings = append(findings, models.SecurityFinding{Check: check, Passed: true, Message: message, Value: value})
+	}
+	fail := func(check, value, severity, message string) {
+		findings = append(findings, models.SecurityFinding{Check: check, Severity: severity, Message: message, Value: value})
+	}
+
+	// Transport security
+	hsts := headers.Get("Strict-Transport-Security")
+	switch {
+	case !https:
+		fail("HTTPS", "", "high", "served over plain HTTP; traffic and cookies can be read or modified in transit")
+	case hsts == "":
+		fail("Strict-Transport-Security", "", "high", "missing; browsers may still connect over plain HTTP")
+	case hstsMaxAge(hsts) < minHSTSMaxAge:
+		fail("Strict-Transport-Security", hsts, "medium", "max-age is shorter than 180 days")
+	default:
+		pass("Strict-Transport-Security", hsts, "enabled")
+	}
+
+	// Content Security Policy
+	csp := headers.Get("Content-Security-Policy")
+	switch {
+	case csp == "":
+		fail("Content-Security-Policy", "", documentSeverity, "missing; injected scripts are not restricted")
+	case strings.Contains(csp, "'unsafe-inline'") || strings.Contains(csp, "'unsafe-eval'"):
+		fail("Content-Security-Policy", csp, "low", "allows 'unsafe-inline' or 'unsafe-eval', which weakens XSS protection")
+	default:
+		pass("Content-Security-Policy", csp, "present")
+	}
+
+	// MIME sniffing
+	if value := headers.Get("X-Content-Type-Options"); strings.EqualFold(strings.TrimSpace(value), "nosniff") {
+		pass("X-Content-Type-Options", value, "nosniff")
+	} else {
+		fail("X-Content-Type-Options", value, "medium", "should be nosniff to stop browsers from guessing the content type")
+	}
+
+	// Clickjacking, either through X-Frame-Options or CSP frame-ancestors
+	frameOptions := headers.Get("X-Frame-Options")
+	switch strings.ToUpper(strings.TrimSpace(frameOptions)) {
+	case "DENY", "SAMEORIGIN":
+		pass("X-Frame-Options", frameOptions, "framing restricted")
+	default:
+		if strings.Contains(csp, "frame-ancestors") {
+			pass("X-Frame-Options", frameOptions, "framing restricted by CSP frame-ancestors")
+		} else {
+			fail("X-Frame-Options", frameOptions, documentSeverity, "should be DENY or SAMEORIGIN (or set CSP frame-ancestors) to prevent clickjacking")
+		}
+	}
+
+	// Referrer leakage
+	referrerPolicy := headers.Get("Referrer-Policy")
+	switch strings.ToLower(strings.TrimSpace(referrerPolicy)) {
+	case "":
+		fail("Referrer-Policy", "", "low", "missing; full URLs may leak to other sites through the Referer header")
+	case "unsafe-url", "no-referrer-when-downgrade":
+		fail("Referrer-Policy", referrerPolicy, "low", "sends full URLs to other sites")
+	default:
+		pass("Referrer-Policy", referrerPolicy, "restricts the Referer header")
+	}
+
+	// Cookies
+	for i, line := range headers.Values("Set-Cookie") {
+		if i == maxAuditedCookies {
+			break
+		}
+		findings = append(findings, auditCookie(line, https)...)
+	}
+
+	score := securityScore(findings)
+	return &models.SecurityReport{
+		Score:    score,
+		Grade:    securityGrade(score),
+		Findings: findings,
+	}
+}
+
+// auditCookie checks the Secure, HttpOnly and SameSite attributes of a Set-Cookie header
+func auditCookie(line string, https bool) []models.SecurityFinding {
+	cookie, err := http.ParseSetCookie(line)
+	if err != nil {
+		return []models.SecurityFinding{{
+			Check:    "Set-Cookie",
+			Severity: "low",
+			Message:  fmt.Sprintf("could not be parsed: %v", err),
+		}}
+	}
+
+	check := "Set-Cookie: " + cookie.Name
+	var problems []string
+	severity := ""
+	raise := func(level string) {
+		if severityPenalty[level] > severityPenalty[severity] {
+			severity = level
+		}
+	}
+
+	if !cookie.Secure {
+		problems = append(problems, "missing Secure")
+		if https {
+			raise("high")
+		} else {
+			raise("medium")
+		}
+	}
+	if !cookie.HttpOnly {
+		problems = append(problems, "missing HttpOnly (readable from JavaScript)")
+		raise("medium")
+	}
+	switch cookie.SameSite {
+	case http.SameSiteLaxMode, http.SameSiteStrictMode:
+	case http.SameSiteNoneMode:
+		if !cookie.Secure {
+			problems = append(problems, "SameSite=None without Secure is rejected by browsers")
+			raise("medium")
+		}
+	default:
+		problems = append(problems, "missing SameSite")
+		raise("low")
+	}
+
+	if len(problems) == 0 {
+		return []models.SecurityFinding{{Check: check, Passed: true, Message: "Secure, HttpOnly and SameSite set"}}
+	}
+	return []models.SecurityFinding{{Check: check, Severity: severity, Message: strings.Join(problems, "; ")}}
+}
+
+// hstsMaxAge returns the max-age directive of a Strict-Transport-Security header, or 0
+func hstsMaxAge(value string) int {
+	for _, directive := range strings.Split(value, ";") {
+		name, arg, ok := strings.Cut(strings.TrimSpace(directive), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "max-age") {
+			maxAge, err := strconv.Atoi(strings.Trim(strings.TrimSpace(arg), `"`))
+			if err == nil {
+				return maxAge
+			}
+		}
+	}
+	return 0
+}
+
+// securityScore deducts the penalty of every failed check from 100
+func securityScore(findings []models.SecurityFinding) int {
+	score := 100
+	for _, finding := range findings {
+		if !finding.Passed {
+			score -= severityPenalty[finding.Severity]
+		}
+	}
+	return max(score, 0)
+}
+
+// securityGrade maps a security score to a letter grade
+func securityGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// describeSecurityReport renders the checks of a security audit for the LLM
+func describeSecurityReport(report *models.SecurityReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- Score: %d/100 (grade %s)\n", report.Score, report.Grade))
+	for _, finding := range report.Findings {
+		if finding.Passed {
+			sb.WriteString(fmt.Sprintf("- PASS %s: %s\n", finding.Check, finding.Message))
+		} else {
+			sb.WriteString(fmt.Sprintf("- FAIL [%s] %s: %s\n", finding.Severity, finding.Check, finding.Message))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// ErrSessionNotFound is returned when a session does not exist or has expired
+var ErrSessionNotFound = errors.New("session not found or expired")
+
+// SessionStore keeps conversation sessions in memory
+type SessionStore struct {
+	mu          sync.Mutex
+	sessions    map[string]*models.Session
+	ttl         time.Duration
+	maxSessions int
+}
+
+// NewSessionStore creates a new in-memory session store
+func NewSessionStore(config *models.SessionConfig) *SessionStore {
+	ttl := 30 * time.Minute
+	if config.TTL > 0 {
+		ttl = time.Duration(config.TTL) * time.Minute
+	}
+
+	maxSessions := 100
+	if config.MaxSessions > 0 {
+		maxSessions = config.MaxSessions
+	}
+
+	return &SessionStore{
+		sessions:    make(map[string]*models.Session),
+		ttl:         ttl,
+		maxSessions: maxSessions,
+	}
+}
+
+// Create starts a new session from an analyzed request/response pair
+func (s *SessionStore) Create(request *models.RequestConfig, response *models.Response, question, analysis string) (*models.Session, error) {
+	id, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &models.Session{
+		ID:       id,
+		Request:  request,
+		Response: response,
+		Messages: []models.ChatMessage{
+			{Role: "user", Content: question},
+			{Role: "assistant", Content: analysis},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpired(now)
+	for len(s.sessions) >= s.maxSessions {
+		s.evictOldest()
+	}
+	s.sessions[id] = session
+
+	return copySession(session), nil
+}
+
+// Get returns a copy of the session with the given ID
+func (s *SessionStore) Get(id string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.removeExpired(time.Now())
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	return copySession(session), nil
+}
+
+// AppendExchange records a follow-up question and its answer
+func (s *SessionStore) AppendExchange(id, question, answer string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.removeExpired(now)
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session.Messages = append(session.Messages,
+		models.ChatMessage{Role: "user", Content: question},
+		models.ChatMessage{Role: "assistant", Content: answer},
+	)
+	session.UpdatedAt = now
+
+	return copySession(session), nil
+}
+
+// SetModel selects the LLM provider and model answering the session's follow-ups
+func (s *SessionStore) SetModel(id, provider, model string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.removeExpired(now)
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	session.Provider = provider
+	session.Model = model
+	session.UpdatedAt = now
+
+	return copySession(session), nil
+}
+
+// Delete removes a session
+func (s *SessionStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.sessions[id]; !ok {
+		return ErrSessionNotFound
+	}
+	delete(s.sessions, id)
+	return nil
+}
+
+// removeExpired drops sessions idle for longer than the TTL (caller must hold the lock)
+func (s *SessionStore) removeExpired(now time.Time) {
+	for id, session := range s.sessions {
+		if now.Sub(session.UpdatedAt) > s.ttl {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// evictOldest drops the least recently used session (caller must hold the lock)
+func (s *SessionStore) evictOldest() {
+	var oldestID string
+	var oldest time.Time
+	for id, session := range s.sessions {
+		if oldestID == "" || session.UpdatedAt.Before(oldest) {
+			oldestID = id
+			oldest = session.UpdatedAt
+		}
+	}
+	delete(s.sessions, oldestID)
+}
+
+// copySession returns a copy that callers can read without holding the lock
+func copySession(session *models.Session) *models.Session {
+	c := *session
+	c.Messages = append([]models.ChatMessage(nil), session.Messages...)
+	return &c
+}
+
+// newSessionID generates a random session identifier
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,271 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// tlsProbeTimeout bounds each handshake of the TLS configuration probe
+const tlsProbeTimeout = 5 * time.Second
+
+// probedTLSVersions are the protocol versions checked, oldest first
+var probedTLSVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// tlsProbe is a TLS configuration probe of one server
+type tlsProbe struct {
+	client     *HTTPClient
+	address    string
+	hostname   string
+	handshakes atomic.Int64 // Handshakes attempted, for the audit log
+}
+
+// probeTLSConfiguration handshakes with the server once per protocol version and enumerates the
+// weak cipher suites it accepts, then grades the result together with the certificate status.
+// Certificates are not verified here; PerformSSLDiagnostics reports on them
+func (c *HTTPClient) probeTLSConfiguration(ctx context.Context, rawURL string, certValid bool, certStatus string) *models.TLSReport {
+	report := &models.TLSReport{Versions: make([]models.TLSVersionSupport, len(probedTLSVersions))}
+	if err := c.validateURL(rawURL); err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		report.Error = fmt.Sprintf("Failed to parse URL: %v", err)
+		return report
+	}
+	probe := &tlsProbe{
+		client:   c,
+		address:  net.JoinHostPort(parsedURL.Hostname(), strconv.Itoa(targetPort(parsedURL))),
+		hostname: parsedURL.Hostname(),
+	}
+
+	// The handshakes count as one outbound request against the concurrency cap
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	defer release()
+
+	start := time.Now()
+	var defaultState *tls.ConnectionState
+	var defaultErr error
+	var wg sync.WaitGroup
+	wg.Add(2 + len(probedTLSVersions))
+	go func() {
+		defer wg.Done()
+		defaultState, defaultErr = probe.handshake(ctx, &tls.Config{})
+	}()
+	go func() {
+		defer wg.Done()
+		report.WeakCipherSuites = probe.acceptedWeakCipherSuites(ctx)
+	}()
+	for i, version := range probedTLSVersions {
+		go func() {
+			defer wg.Done()
+			report.Versions[i] = probe.version(ctx, version)
+		}()
+	}
+	wg.Wait()
+
+	if defaultErr != nil {
+		report.Error = fmt.Sprintf("handshake with default settings failed: %v", defaultErr)
+	} else {
+		report.Version = tls.VersionName(defaultState.Version)
+		report.CipherSuite = tls.CipherSuiteName(defaultState.CipherSuite)
+	}
+	c.auditProbe(ctx, "tls", "tls://"+probe.address, int(probe.handshakes.Load()), report.Error, start)
+
+	report.Findings = tlsFindings(report, certValid, certStatus)
+	report.Score = securityScore(report.Findings)
+	report.Grade = securityGrade(report.Score)
+	return report
+}
+
+// version checks whether the server accepts one protocol version, offering every cipher
+// suite so the server's preferred one shows
+func (p *tlsProbe) version(ctx context.Context, version uint16) models.TLSVersionSupport {
+	support := models.TLSVersionSupport{Version: tls.VersionName(version)}
+	config := &tls.Config{MinVersion: version, MaxVersion: version}
+	if version < tls.VersionTLS13 {
+		config.CipherSuites = allCipherSuites() // TLS 1.3 suites are not configurable
+	}
+
+	state, err := p.handshake(ctx, config)
+	if err != nil {
+		return support
+	}
+	support.Supported = state.Version == version
+	support.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	return support
+}
+
+// acceptedWeakCipherSuites offers only weak suites and removes each one the server picks until
+// it refuses the rest
+func (p *tlsProbe) acceptedWeakCipherSuites(ctx context.Context) []string {
+	offered := weakCipherSuites()
+	var accepted []string
+	for len(offered) > 0 {
+		state, err := p.handshake(ctx, &tls.Config{
+			MinVersion:   tls.VersionTLS10,
+			MaxVersion:   tls.VersionTLS12,
+			CipherSuites: offered,
+		})
+		if err != nil || !slices.Contains(offered, state.CipherSuite) {
+			break
+		}
+		accepted = append(accepted, tls.CipherSuiteName(state.CipherSuite))
+		offered = slices.DeleteFunc(offered, func(id uint16) bool { return id == state.CipherSuite })
+	}
+	return accepted
+}
+
+// handshake connects through the client's dialer and completes a handshake without verifying
+// the certificate
+func (p *tlsProbe) handshake(ctx context.Context, config *tls.Config) (*tls.ConnectionState, error) {
+	config.ServerName = p.hostname
+	config.InsecureSkipVerify = true
+
+	ctx, cancel := context.WithTimeout(ctx, tlsProbeTimeout)
+	defer cancel()
+
+	p.handshakes.Add(1)
+	rawConn, err := p.client.dialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(rawConn, config)
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, err
+	}
+
+	state := conn.ConnectionState()
+	return &state, nil
+}
+
+// tlsFindings rates the accepted versions and cipher suites and the certificate status
+func tlsFindings(report *models.TLSReport, certValid bool, certStatus string) []models.SecurityFinding {
+	var findings []models.SecurityFinding
+	pass := func(check, value, message string) {
+		findings = append(findings, models.SecurityFinding{Check: check, Passed: true, Message: message, Value: value})
+	}
+	fail := func(check, value, severity, message string) {
+		findings = append(findings, models.SecurityFinding{Check: check, Severity: severity, Message: message, Value: value})
+	}
+
+	supported := make(map[string]bool, len(report.Versions))
+	for _, version := range report.Versions {
+		supported[version.Version] = version.Supported
+	}
+
+	// Protocol versions
+	for _, name := range []string{"TLS 1.0", "TLS 1.1"} {
+		if supported[name] {
+			fail(name, "", "medium", "accepted; it is deprecated (RFC 8996) and should be disabled")
+		} else {
+			pass(name, "", "disabled")
+		}
+	}
+	switch {
+	case supported["TLS 1.2"]:
+		pass("TLS 1.2", "", "accepted")
+	case supported["TLS 1.3"]:
+		pass("TLS 1.2", "", "not accepted; the server only speaks TLS 1.3")
+	default:
+		fail("TLS 1.2", "", "high", "neither TLS 1.2 nor TLS 1.3 is accepted")
+	}
+	if supported["TLS 1.3"] {
+		pass("TLS 1.3", "", "accepted")
+	} else {
+		fail("TLS 1.3", "", "low", "not accepted; TLS 1.3 is faster and drops legacy cryptography")
+	}
+
+	// Cipher suites
+	var insecure, noForwardSecrecy []string
+	for _, name := range report.WeakCipherSuites {
+		if isInsecureCipherSuite(name) {
+			insecure = append(insecure, name)
+		} else {
+			noForwardSecrecy = append(noForwardSecrecy, name)
+		}
+	}
+	if len(insecure) > 0 {
+		fail("Insecure cipher suites", strings.Join(insecure, ", "), "high", "accepted; they use broken algorithms such as RC4, 3DES or CBC with SHA-256")
+	} else {
+		pass("Insecure cipher suites", "", "none accepted")
+	}
+	if len(noForwardSecrecy) > 0 {
+		fail("Forward secrecy", strings.Join(noForwardSecrecy, ", "), "medium", "RSA key exchange suites are accepted; recorded traffic can be decrypted if the key leaks")
+	} else {
+		pass("Forward secrecy", "", "every accepted suite uses ephemeral key exchange")
+	}
+
+	// Certificate
+	if certValid {
+		pass("Certificate", certStatus, "valid")
+	} else {
+		fail("Certificate", certStatus, "high", "not valid; clients will refuse the connection")
+	}
+	return findings
+}
+
+// allCipherSuites lists every TLS 1.0-1.2 suite the client implements, insecure ones included
+func allCipherSuites() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		ids = append(ids, suite.ID)
+	}
+	return ids
+}
+
+// weakCipherSuites lists the insecure suites and those without forward secrecy
+func weakCipherSuites() []uint16 {
+	var ids []uint16
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if isInsecureCipherSuite(suite.Name) || strings.HasPrefix(suite.Name, "TLS_RSA_") {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids
+}
+
+// isInsecureCipherSuite reports whether a suite uses a broken cipher (RC4, 3DES) or CBC with
+// SHA-256, which is open to Lucky13-style attacks
+func isInsecureCipherSuite(name string) bool {
+	return strings.Contains(name, "_RC4_") || strings.Contains(name, "_3DES_") || strings.HasSuffix(name, "_CBC_SHA256")
+}
+
+// describeTLSReport renders a TLS configuration report for the LLM
+func describeTLSReport(report *models.TLSReport) string {
+	var sb strings.Builder
+	if report.Version != "" {
+		sb.WriteString(fmt.Sprintf("- Negotiated: %s, %s\n", report.Version, report.CipherSuite))
+	}
+	var accepted []string
+	for _, version := range report.Versions {
+		if version.Supported {
+			accepted = append(accepted, version.Version)
+		}
+	}
+	if len(accepted) == 0 {
+		accepted = []string{"none"}
+	}
+	sb.WriteString(fmt.Sprintf("- Accepted versions: %s\n", strings.Join(accepted, ", ")))
+	if report.Error != "" {
+		sb.WriteString(fmt.Sprintf("- Error: %s\n", report.Error))
+	}
+	sb.WriteString(describeSecurityReport(&models.SecurityReport{Score: report.Score, Grade: report.Grade, Findings: report.Findings}))
+	return sb.String()
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans created by the agent
+const tracerName = "github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/agent"
+
+// tracedLLMClient records a span around every LLM call; the HTTP call to the provider is a child span
+type tracedLLMClient struct {
+	LLMClient
+	provider string
+	model    string
+}
+
+// Chat traces a conversation turn
+func (c *tracedLLMClient) Chat(ctx context.Context, systemPrompt string, messages []models.ChatMessage) (string, error) {
+	ctx, span := c.start(ctx, "llm.chat")
+	defer span.End()
+	span.SetAttributes(attribute.Int("llm.messages", len(messages)))
+
+	reply, err := c.LLMClient.Chat(ctx, systemPrompt, messages)
+	recordError(span, err)
+	return reply, err
+}
+
+// start opens an LLM span carrying the provider and model
+func (c *tracedLLMClient) start(ctx context.Context, name string) (context.Context, trace.Span) {
+	attributes := []attribute.KeyValue{attribute.String("gen_ai.system", c.provider)}
+	if c.model != "" {
+		attributes = append(attributes, attribute.String("gen_ai.request.model", c.model))
+	}
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+}
+
+// recordError marks a span as failed
+func recordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// newLLMHTTPClient creates the HTTP client of an LLM provider, traced, logged and propagating
+// the trace context and request ID
+func newLLMHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: otelhttp.NewTransport(&telemetry.Transport{Base: http.DefaultTransport, Component: "llm"}),
+		Timeout:   timeout,
+	}
+}
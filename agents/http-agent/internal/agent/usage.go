@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"context"
+	"sync"
+)
+
+// usageKey is the context key of the token usage accumulator
+type usageKey struct{}
+
+// tokenUsage accumulates the tokens reported by LLM providers during a call
+type tokenUsage struct {
+	mu     sync.Mutex
+	input  int
+	output int
+}
+
+// withTokenUsage returns a context whose LLM calls add their token usage to the returned accumulator
+func withTokenUsage(ctx context.Context) (context.Context, *tokenUsage) {
+	usage := &tokenUsage{}
+	return context.WithValue(ctx, usageKey{}, usage), usage
+}
+
+// recordTokenUsage adds the tokens of an LLM call to the context's accumulator, if any
+func recordTokenUsage(ctx context.Context, input, output int) {
+	usage, ok := ctx.Value(usageKey{}).(*tokenUsage)
+	if !ok {
+		return
+	}
+	usage.mu.Lock()
+	defer usage.mu.Unlock()
+	usage.input += input
+	usage.output += output
+}
+
+// totals returns the accumulated input and output tokens
+func (u *tokenUsage) totals() (int, int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.input, u.output
+}
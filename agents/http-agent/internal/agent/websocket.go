@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// defaultWebSocketMessages is the number of frames captured when none is requested
+	defaultWebSocketMessages = 10
+
+	// defaultWebSocketDuration is the capture time in seconds when none is requested
+	defaultWebSocketDuration = 10
+
+	// maxWebSocketDuration caps how long a connection is kept open, in seconds
+	maxWebSocketDuration = 120
+)
+
+// isWebSocketURL reports whether the URL uses the ws or wss scheme
+func isWebSocketURL(rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return parsedURL.Scheme == "ws" || parsedURL.Scheme == "wss"
+}
+
+// makeWebSocketRequest connects to a WebSocket endpoint, sends the configured frames
+// and captures the frames received until the message limit or capture duration is reached
+func (c *HTTPClient) makeWebSocketRequest(ctx context.Context, reqConfig *models.RequestConfig, verifySSL bool) (*models.Response, error) {
+	startTime := time.Now()
+
+	options := models.WebSocketOptions{}
+	if reqConfig.WebSocket != nil {
+		options = *reqConfig.WebSocket
+	}
+	if len(options.Messages) == 0 && reqConfig.Body != "" {
+		options.Messages = []string{reqConfig.Body}
+	}
+	if options.MaxMessages <= 0 {
+		options.MaxMessages = defaultWebSocketMessages
+	}
+	if options.Duration <= 0 {
+		options.Duration = defaultWebSocketDuration
+	}
+	if options.Duration > maxWebSocketDuration {
+		options.Duration = maxWebSocketDuration
+	}
+
+	dialer := &websocket.Dialer{
+		NetDialContext:   c.dialContext,
+		TLSClientConfig:  c.tlsConfig(verifySSL),
+		HandshakeTimeout: time.Duration(c.config.Timeout) * time.Second,
+		Jar:              c.cookieJar(reqConfig.CookieJar),
+	}
+
+	header := http.Header{}
+	for key, value := range reqConfig.Headers {
+		header.Set(key, value)
+	}
+	if header.Get("User-Agent") == "" {
+		header.Set("User-Agent", "Intelligent-HTTP-Agent/1.0")
+	}
+	if id := telemetry.RequestID(ctx); id != "" && header.Get(telemetry.RequestIDHeader) == "" {
+		header.Set(telemetry.RequestIDHeader, id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+
+	conn, resp, err := dialer.DialContext(ctx, reqConfig.URL, header)
+	if err != nil {
+		// A rejected handshake is still a response worth analyzing
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			return c.handshakeFailureResponse(resp, startTime)
+		}
+		return nil, fmt.Errorf("failed to open WebSocket connection: %w", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(c.maxResponseSize)
+
+	response := &models.Response{
+		StatusCode:  resp.StatusCode,
+		Status:      resp.Status,
+		Headers:     resp.Header,
+		ContentType: "websocket",
+		Timestamp:   startTime,
+		Frames:      []models.WebSocketFrame{},
+	}
+
+	for _, message := range options.Messages {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(message)); err != nil {
+			return nil, fmt.Errorf("failed to send WebSocket message: %w", err)
+		}
+		response.Frames = append(response.Frames, models.WebSocketFrame{
+			Direction: "sent",
+			Type:      "text",
+			Data:      message,
+			Offset:    FormatDuration(time.Since(startTime)),
+		})
+	}
+
+	deadline := startTime.Add(time.Duration(options.Duration) * time.Second)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetReadDeadline(deadline)
+
+	received := 0
+	for received < options.MaxMessages {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			var netErr net.Error
+			var closeErr *websocket.CloseError
+			switch {
+			case errors.As(err, &closeErr):
+				response.Frames = append(response.Frames, models.WebSocketFrame{
+					Direction: "received",
+					Type:      "close",
+					Data:      fmt.Sprintf("%d %s", closeErr.Code, closeErr.Text),
+					Offset:    FormatDuration(time.Since(startTime)),
+				})
+			case errors.As(err, &netErr) && netErr.Timeout():
+				// Timeouts simply end the capture window
+			default:
+				response.Frames = append(response.Frames, models.WebSocketFrame{
+					Direction: "received",
+					Type:      "error",
+					Data:      err.Error(),
+					Offset:    FormatDuration(time.Since(startTime)),
+				})
+			}
+			break
+		}
+
+		frame := models.WebSocketFrame{
+			Direction: "received",
+			Type:      "text",
+			Data:      string(data),
+			Offset:    FormatDuration(time.Since(startTime)),
+		}
+		if messageType == websocket.BinaryMessage {
+			frame.Type = "binary"
+			frame.Data = base64.StdEncoding.EncodeToString(data)
+		}
+		response.Frames = append(response.Frames, frame)
+		response.ContentLength += int64(len(data))
+		received++
+	}
+
+	conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+
+	response.Duration = time.Since(startTime)
+	return response, nil
+}
+
+// handshakeFailureResponse converts a rejected WebSocket upgrade into a response
+func (c *HTTPClient) handshakeFailureResponse(resp *http.Response, startTime time.Time) (*models.Response, error) {
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, c.maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return &models.Response{
+		StatusCode:    resp.StatusCode,
+		Status:        resp.Status,
+		Headers:       resp.Header,
+		Body:          string(bodyBytes),
+		Duration:      time.Since(startTime),
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		Timestamp:     startTime,
+	}, nil
+}
@@ -0,0 +1,270 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+// maxWorkflowSteps limits the number of requests in a single workflow
+const maxWorkflowSteps = 20
+
+// placeholderPattern matches {{variable}} placeholders in workflow requests
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// RunWorkflow executes the workflow steps in order, passing extracted values to later
+// steps, stops at the first failing step and asks the LLM to summarize the flow
+func (a *HTTPAgent) RunWorkflow(ctx context.Context, workflow *models.Workflow) (*models.WorkflowResult, error) {
+	if len(workflow.Steps) > maxWorkflowSteps {
+		return nil, fmt.Errorf("workflows are limited to %d steps", maxWorkflowSteps)
+	}
+
+	variables := make(map[string]string, len(workflow.Variables))
+	var secrets map[string]string
+	if workflow.Environment != "" {
+		envVariables, envSecrets, err := a.environmentVariables(workflow.Environment)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range envVariables {
+			variables[name] = value
+		}
+		secrets = envSecrets
+	}
+	for name, value := range workflow.Variables {
+		variables[name] = value
+	}
+
+	result := &models.WorkflowResult{
+		Name:      workflow.Name,
+		Steps:     make([]models.WorkflowStepResult, 0, len(workflow.Steps)),
+		Variables: variables,
+	}
+
+	for i, step := range workflow.Steps {
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("Step %d", i+1)
+		}
+		if step.Request.CookieJar == "" {
+			step.Request.CookieJar = workflow.CookieJar
+		}
+
+		stepResult := a.runWorkflowStep(ctx, &step, variables)
+		maskRequest(stepResult.Request, secrets)
+		maskResponse(stepResult.Response, secrets)
+		stepResult.Error = maskSecrets(stepResult.Error, secrets)
+		for j := range stepResult.Assertions {
+			stepResult.Assertions[j].Actual = maskSecrets(stepResult.Assertions[j].Actual, secrets)
+		}
+		result.Steps = append(result.Steps, stepResult)
+		if stepResult.Error != "" {
+			result.FailedStep = step.Name
+			break
+		}
+	}
+
+	// Secrets from the environment are reported as their placeholders
+	for name, value := range variables {
+		if _, ok := secrets[value]; ok {
+			variables[name] = secrets[value]
+		}
+	}
+
+	summary, err := a.chat(ctx, "", []models.ChatMessage{
+		{Role: "user", Content: buildWorkflowPrompt(result, workflow.Prompt)},
+	})
+	if err != nil {
+		summary = fmt.Sprintf("Summary unavailable: %v", err)
+	}
+	result.Summary = summary
+
+	return result, nil
+}
+
+// runWorkflowStep substitutes variables, executes the request and extracts new variables
+func (a *HTTPAgent) runWorkflowStep(ctx context.Context, step *models.WorkflowStep, variables map[string]string) models.WorkflowStepResult {
+	reqConfig := step.Request
+	substituteRequest(&reqConfig, variables)
+	reqConfig.Method = strings.ToUpper(reqConfig.Method)
+	if reqConfig.Method == "" {
+		reqConfig.Method = "GET"
+	}
+
+	stepResult := models.WorkflowStepResult{Name: step.Name, Request: &reqConfig}
+
+	// Shape GraphQL operations into a JSON POST request, like single requests
+	if reqConfig.GraphQL != nil {
+		if err := prepareGraphQLRequest(&reqConfig); err != nil {
+			stepResult.Error = err.Error()
+			return stepResult
+		}
+	}
+
+	// The body carries the GraphQL query and variables, so they are checked too
+	unresolved := reqConfig.URL + reqConfig.Body
+	for _, value := range reqConfig.Headers {
+		unresolved += value
+	}
+	if missing := placeholderPattern.FindStringSubmatch(unresolved); missing != nil {
+		stepResult.Error = fmt.Sprintf("undefined variable %q", missing[1])
+		return stepResult
+	}
+
+	response, err := a.httpClient.MakeRequest(ctx, &reqConfig)
+	if err != nil {
+		stepResult.Error = err.Error()
+		return stepResult
+	}
+	stepResult.Response = response
+	stepResult.Duration = FormatDuration(response.Duration)
+
+	if step.ExpectStatus != 0 && response.StatusCode != step.ExpectStatus {
+		stepResult.Error = fmt.Sprintf("expected status %d, got %d", step.ExpectStatus, response.StatusCode)
+		return stepResult
+	}
+	if step.ExpectStatus == 0 && response.StatusCode >= 400 {
+		stepResult.Error = fmt.Sprintf("request failed with status %s", response.Status)
+		return stepResult
+	}
+
+	stepResult.Assertions = evaluateAssertions(reqConfig.Assertions, response)
+	if !assertionsPassed(stepResult.Assertions) {
+		stepResult.Error = "assertions failed"
+		return stepResult
+	}
+
+	stepResult.Extracted = make(map[string]string, len(step.Extract))
+	for name, source := range step.Extract {
+		value, err := extractValue(response, source)
+		if err != nil {
+			stepResult.Error = fmt.Sprintf("failed to extract %s: %v", name, err)
+			return stepResult
+		}
+		stepResult.Extracted[name] = value
+		variables[name] = value
+	}
+
+	return stepResult
+}
+
+// extractValue reads a value from a response using a JSONPath or jq-style path, header:Name or status source
+func extractValue(response *models.Response, source string) (string, error) {
+	source = strings.TrimSpace(source)
+	switch {
+	case source == "status":
+		return strconv.Itoa(response.StatusCode), nil
+	case strings.HasPrefix(source, "header:"):
+		name := strings.TrimSpace(strings.TrimPrefix(source, "header:"))
+		value := http.Header(response.Headers).Get(name)
+		if value == "" {
+			return "", fmt.Errorf("header %s not present", name)
+		}
+		return value, nil
+	case strings.HasPrefix(source, "$"), strings.HasPrefix(source, "."):
+		value, err := evaluateJSONPath(response.Body, source)
+		if err != nil {
+			return "", err
+		}
+		return jsonValueString(value), nil
+	default:
+		return "", fmt.Errorf("unsupported source %q (use a JSONPath such as $.id or .id, header:Name or status)", source)
+	}
+}
+
+// substituteRequest fills in placeholders in the URL, header values, body, WebSocket messages,
+// GraphQL operation and auth block
+func substituteRequest(reqConfig *models.RequestConfig, variables map[string]string) {
+	reqConfig.URL = substituteVariables(reqConfig.URL, variables)
+	reqConfig.Body = substituteVariables(reqConfig.Body, variables)
+	headers := make(map[string]string, len(reqConfig.Headers))
+	for key, value := range reqConfig.Headers {
+		headers[key] = substituteVariables(value, variables)
+	}
+	reqConfig.Headers = headers
+	if reqConfig.WebSocket != nil {
+		options := *reqConfig.WebSocket
+		options.Messages = make([]string, len(reqConfig.WebSocket.Messages))
+		for i, message := range reqConfig.WebSocket.Messages {
+			options.Messages[i] = substituteVariables(message, variables)
+		}
+		reqConfig.WebSocket = &options
+	}
+	reqConfig.GraphQL = mapGraphQLStrings(reqConfig.GraphQL, func(text string) string {
+		return substituteVariables(text, variables)
+	})
+	reqConfig.Auth = substituteAuth(reqConfig.Auth, variables)
+}
+
+// substituteVariables replaces {{variable}} placeholders with known values
+func substituteVariables(text string, variables map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		if value, ok := variables[name]; ok {
+			return value
+		}
+		return match
+	})
+}
+
+// buildWorkflowPrompt describes every executed step for the workflow summary
+func buildWorkflowPrompt(result *models.WorkflowResult, question string) string {
+	var sb strings.Builder
+
+	sb.WriteString("Multi-step HTTP Workflow")
+	if result.Name != "" {
+		sb.WriteString(": " + result.Name)
+	}
+	sb.WriteString("\n")
+
+	for i, step := range result.Steps {
+		sb.WriteString(fmt.Sprintf("\n%d. %s — %s %s\n", i+1, step.Name, step.Request.Method, step.Request.URL))
+		if step.Response != nil {
+			sb.WriteString(fmt.Sprintf("- Status: %s in %s\n", step.Response.Status, step.Duration))
+			if step.Response.Body != "" {
+				body := step.Response.Body
+				if len(body) > 500 {
+					body = body[:500] + "... (truncated)"
+				}
+				sb.WriteString(fmt.Sprintf("- Response Body: %s\n", body))
+			}
+		}
+
+		names := make([]string, 0, len(step.Extracted))
+		for name := range step.Extracted {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// Extracted values are often credentials; only their presence matters here
+			sb.WriteString(fmt.Sprintf("- Extracted %s (%d characters)\n", name, len(step.Extracted[name])))
+		}
+
+		if len(step.Assertions) > 0 {
+			sb.WriteString("- Assertions:\n" + describeAssertions(step.Assertions))
+		}
+
+		if step.Error != "" {
+			sb.WriteString(fmt.Sprintf("- FAILED: %s\n", step.Error))
+		}
+	}
+
+	if result.FailedStep != "" {
+		sb.WriteString(fmt.Sprintf("\nThe workflow stopped at %q; later steps were not executed.\n", result.FailedStep))
+	} else {
+		sb.WriteString("\nAll steps completed.\n")
+	}
+
+	if question == "" {
+		question = "Summarize the workflow and explain where and why it broke, if it did."
+	}
+	sb.WriteString(fmt.Sprintf("\nUser Question: %s\n", question))
+	sb.WriteString("\nProvide a clear and helpful answer:")
+
+	return sb.String()
+}
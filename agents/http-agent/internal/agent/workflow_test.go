@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+)
+
+func TestRunWorkflowStepGraphQL(t *testing.T) {
+	var got struct {
+		Query         string                 `json:"query"`
+		Variables     map[string]interface{} `json:"variables"`
+		OperationName string                 `json:"operationName"`
+	}
+	var method, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, contentType = r.Method, r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"order":{"status":"shipped"}}}`))
+	}))
+	defer server.Close()
+
+	httpClient, err := NewHTTPClient(&models.HTTPConfig{Timeout: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &HTTPAgent{httpClient: httpClient}
+
+	step := &models.WorkflowStep{
+		Name: "Order status",
+		Request: models.RequestConfig{
+			URL: server.URL + "/graphql",
+			GraphQL: &models.GraphQLRequest{
+				Query:         `query Order($id: ID!) { order(id: $id) { status } }`,
+				Variables:     map[string]interface{}{"id": "{{order_id}}", "filter": map[string]interface{}{"tags": []interface{}{"{{tag}}"}}},
+				OperationName: "Order",
+			},
+		},
+		Extract: map[string]string{"status": "$.data.order.status"},
+	}
+	variables := map[string]string{"order_id": "42", "tag": "priority"}
+
+	result := a.runWorkflowStep(context.Background(), step, variables)
+	if result.Error != "" {
+		t.Fatalf("step failed: %s", result.Error)
+	}
+	if method != http.MethodPost || contentType != "application/json" {
+		t.Errorf("sent %s with Content-Type %q, want POST with application/json", method, contentType)
+	}
+	if got.OperationName != "Order" || got.Query != step.Request.GraphQL.Query {
+		t.Errorf("operation = %q %q, want the step's operation", got.OperationName, got.Query)
+	}
+	if got.Variables["id"] != "42" {
+		t.Errorf("variables.id = %v, want 42", got.Variables["id"])
+	}
+	if tags := got.Variables["filter"].(map[string]interface{})["tags"].([]interface{}); tags[0] != "priority" {
+		t.Errorf("variables.filter.tags = %v, want [priority]", tags)
+	}
+	if variables["status"] != "shipped" {
+		t.Errorf("extracted status = %q, want shipped", variables["status"])
+	}
+	if step.Request.GraphQL.Variables["id"] != "{{order_id}}" {
+		t.Errorf("the workflow definition was modified: %v", step.Request.GraphQL.Variables["id"])
+	}
+}
+
+func TestRunWorkflowStepGraphQLUndefinedVariable(t *testing.T) {
+	a := &HTTPAgent{}
+	step := &models.WorkflowStep{
+		Request: models.RequestConfig{
+			URL: "http://127.0.0.1:1/graphql",
+			GraphQL: &models.GraphQLRequest{
+				Query:     `query Order($id: ID!) { order(id: $id) { status } }`,
+				Variables: map[string]interface{}{"id": "{{order_id}}"},
+			},
+		},
+	}
+
+	result := a.runWorkflowStep(context.Background(), step, map[string]string{})
+	if result.Error != `undefined variable "order_id"` {
+		t.Errorf("error = %q, want the undefined variable to be reported", result.Error)
+	}
+}
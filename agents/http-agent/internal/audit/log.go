@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+)
+
+// Sources of outbound requests
+const (
+	SourceAPI     = "api"
+	SourceMonitor = "monitor"
+)
+
+// defaultListLimit is the number of entries returned when a list does not set a limit
+const defaultListLimit = 100
+
+// csvHeader lists the columns of CSV exports
+var csvHeader = []string{"timestamp", "request_id", "source", "client_ip", "user_agent", "principal", "monitor_id",
+	"protocol", "method", "url", "status", "error", "duration_ms", "requests"}
+
+// Actor describes who caused the outbound requests made while handling a context
+type Actor struct {
+	Source    string
+	ClientIP  string
+	UserAgent string
+	Principal string // Authenticated API caller
+	MonitorID string
+}
+
+// actorKey is the context key of the actor
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor of the requests made with it
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// WithPrincipal returns a context whose actor is the authenticated caller with the given name
+func WithPrincipal(ctx context.Context, name string) context.Context {
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	actor.Principal = name
+	return WithActor(ctx, actor)
+}
+
+// Log is an append-only JSON Lines file of outbound requests. Entries are only ever appended;
+// the log is meant to be rotated or shipped by external tooling
+type Log struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// Open opens (or creates) the audit log at the given path for appending
+func Open(path string) (*Log, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{path: path, file: file}, nil
+}
+
+// Close closes the audit log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Record appends an entry, filling in the time, request ID and actor from the context.
+// Failures are logged: an audit problem must not break the request being audited
+func (l *Log) Record(ctx context.Context, entry models.AuditEntry) {
+	if l == nil {
+		return
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UTC()
+	}
+	entry.RequestID = telemetry.RequestID(ctx)
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	entry.Source = actor.Source
+	if entry.Source == "" {
+		entry.Source = SourceAPI
+	}
+	entry.ClientIP = actor.ClientIP
+	entry.UserAgent = actor.UserAgent
+	entry.Principal = actor.Principal
+	entry.MonitorID = actor.MonitorID
+	entry.URL = sanitizeURL(entry.URL)
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode audit entry", "error", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		slog.ErrorContext(ctx, "failed to write audit entry", "error", err)
+	}
+}
+
+// List returns the most recent entries matching the filter, newest first
+func (l *Log) List(filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	var entries []models.AuditEntry
+	err := l.scan(filter, func(entry *models.AuditEntry) error {
+		entries = append(entries, *entry)
+		if len(entries) > limit {
+			entries = entries[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries, nil
+}
+
+// Export writes the entries matching the filter, oldest first, as JSON Lines or CSV
+func (l *Log) Export(w io.Writer, filter *models.AuditFilter) error {
+	if strings.EqualFold(filter.Format, "csv") {
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvHeader); err != nil {
+			return err
+		}
+		err := l.scan(filter, func(entry *models.AuditEntry) error {
+			return writer.Write([]string{
+				entry.Timestamp.Format(time.RFC3339Nano), entry.RequestID, entry.Source, entry.ClientIP, entry.UserAgent,
+				entry.Principal, entry.MonitorID, entry.Protocol, entry.Method, entry.URL, strconv.Itoa(entry.Status), entry.Error,
+				strconv.FormatInt(entry.Duration, 10), strconv.Itoa(entry.Requests),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	encoder := json.NewEncoder(w)
+	return l.scan(filter, func(entry *models.AuditEntry) error {
+		return encoder.Encode(entry)
+	})
+}
+
+// scan reads the log from the start and calls fn for each entry matching the filter
+func (l *Log) scan(filter *models.AuditFilter, fn func(*models.AuditEntry) error) error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // A line cut short by a crash
+		}
+		if !matches(&entry, filter) {
+			continue
+		}
+		if err := fn(&entry); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+// matches reports whether an entry passes the filter
+func matches(entry *models.AuditEntry, filter *models.AuditFilter) bool {
+	if !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && !entry.Timestamp.Before(filter.Until) {
+		return false
+	}
+	if filter.Host != "" {
+		parsed, err := url.Parse(entry.URL)
+		if err != nil || !strings.Contains(strings.ToLower(parsed.Host), strings.ToLower(filter.Host)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeURL drops the query string, fragment and credentials, which may carry secrets
+func sanitizeURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+// Transport records every round trip of an HTTP client, including each redirect hop
+type Transport struct {
+	Base http.RoundTripper
+	Log  *Log
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+
+	entry := models.AuditEntry{
+		Timestamp: start.UTC(),
+		Protocol:  "http",
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		Duration:  time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Status = resp.StatusCode
+	}
+	t.Log.Record(req.Context(), entry)
+
+	return resp, err
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// ErrUnauthenticated is returned when a credential is missing, unknown or invalid
+var ErrUnauthenticated = errors.New("invalid or missing credentials")
+
+// Role grants access to a set of endpoints; each role includes the access of the roles below it
+type Role int
+
+// Roles, from least to most privileged
+const (
+	RoleViewer   Role = iota + 1 // Reads history, sessions and configuration
+	RoleOperator                 // Executes requests
+	RoleAdmin                    // Changes configuration and reads the audit log
+)
+
+// String returns the configuration name of the role
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// ParseRole parses a role name
+func ParseRole(name string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return 0, fmt.Errorf("unknown role %q (use viewer, operator or admin)", name)
+	}
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	Name   string // API key name or token subject
+	Role   Role
+	Method string // api_key or oidc
+}
+
+// apiKey is a configured key, kept only as a digest
+type apiKey struct {
+	name   string
+	digest [sha256.Size]byte
+	role   Role
+}
+
+// Authenticator checks the credentials of API callers
+type Authenticator struct {
+	keys      []apiKey
+	verifier  *oidc.IDTokenVerifier
+	roleClaim string
+}
+
+// New creates an authenticator for the configured API keys and OIDC issuer. The issuer's
+// discovery document is fetched once, so the identity provider must be reachable at startup
+func New(ctx context.Context, config *models.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{roleClaim: config.OIDC.RoleClaim}
+	if a.roleClaim == "" {
+		a.roleClaim = "roles"
+	}
+
+	for i, key := range config.APIKeys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("api key %d has no key", i+1)
+		}
+		role, err := ParseRole(key.Role)
+		if err != nil {
+			return nil, fmt.Errorf("invalid role for api key %q: %w", key.Name, err)
+		}
+		name := key.Name
+		if name == "" {
+			name = fmt.Sprintf("key-%d", i+1)
+		}
+		a.keys = append(a.keys, apiKey{name: name, digest: sha256.Sum256([]byte(key.Key)), role: role})
+	}
+
+	if config.OIDC.Issuer != "" {
+		provider, err := oidc.NewProvider(ctx, config.OIDC.Issuer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover OIDC issuer: %w", err)
+		}
+		a.verifier = provider.Verifier(&oidc.Config{
+			ClientID:          config.OIDC.Audience,
+			SkipClientIDCheck: config.OIDC.Audience == "",
+		})
+	}
+
+	if len(a.keys) == 0 && a.verifier == nil {
+		return nil, errors.New("authentication is enabled but neither api_keys nor an OIDC issuer is configured")
+	}
+	return a, nil
+}
+
+// Authenticate resolves a bearer credential: a configured API key or, when OIDC is
+// configured, a signed token from the issuer carrying a role in its role claim
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if credential == "" {
+		return nil, ErrUnauthenticated
+	}
+
+	// Every key is compared so the time taken does not reveal which one matched
+	digest := sha256.Sum256([]byte(credential))
+	var match *apiKey
+	for i := range a.keys {
+		if subtle.ConstantTimeCompare(digest[:], a.keys[i].digest[:]) == 1 {
+			match = &a.keys[i]
+		}
+	}
+	if match != nil {
+		return &Principal{Name: match.name, Role: match.role, Method: "api_key"}, nil
+	}
+
+	if a.verifier == nil || strings.Count(credential, ".") != 2 {
+		return nil, ErrUnauthenticated
+	}
+	token, err := a.verifier.Verify(ctx, credential)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	var claims map[string]interface{}
+	if err := token.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+	role := highestRole(claims[a.roleClaim])
+	if role == 0 {
+		return nil, fmt.Errorf("%w: token has no viewer, operator or admin role in claim %q", ErrUnauthenticated, a.roleClaim)
+	}
+
+	name := token.Subject
+	if email, ok := claims["email"].(string); ok && email != "" {
+		name = email
+	}
+	return &Principal{Name: name, Role: role, Method: "oidc"}, nil
+}
+
+// highestRole returns the most privileged role named by a claim holding a string or a list of strings
+func highestRole(claim interface{}) Role {
+	var names []string
+	switch value := claim.(type) {
+	case string:
+		names = strings.Fields(strings.ReplaceAll(value, ",", " "))
+	case []interface{}:
+		for _, item := range value {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	var highest Role
+	for _, name := range names {
+		if role, err := ParseRole(name); err == nil && role > highest {
+			highest = role
+		}
+	}
+	return highest
+}
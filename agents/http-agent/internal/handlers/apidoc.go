@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// apiVersion is the version of the API document, bumped with each schema change
+const apiVersion = "1.0.0"
+
+// swaggerUIVersion is the Swagger UI release loaded by the docs page
+const swaggerUIVersion = "5.17.14"
+
+// handleAPIDocument serves the OpenAPI 3.0 document describing the API
+func (h *Handler) handleAPIDocument(c *gin.Context) {
+	c.JSON(http.StatusOK, h.apiDocument())
+}
+
+// handleAPIDocs serves Swagger UI for the API document
+func (h *Handler) handleAPIDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>HTTP Agent API</title>
+  <link rel="icon" href="/static/favicon.svg">
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@`+swaggerUIVersion+`/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@`+swaggerUIVersion+`/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "`+APIPrefix+`/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+}
+
+// apiDocument generates the OpenAPI document from the route table
+func (h *Handler) apiDocument() gin.H {
+	schemas := schemaRegistry{}
+	errorResponse := gin.H{
+		"description": "Error",
+		"content":     gin.H{"application/json": gin.H{"schema": schemas.schemaOf(reflect.TypeOf(models.ErrorResponse{}))}},
+	}
+
+	paths := gin.H{}
+	for _, rt := range h.routes() {
+		path, parameters := openAPIPath(rt.path)
+		if rt.query != nil {
+			parameters = append(parameters, queryParameters(reflect.TypeOf(rt.query))...)
+		}
+
+		operation := gin.H{
+			"tags":        []string{rt.tag},
+			"summary":     rt.summary,
+			"operationId": operationID(rt),
+			"responses":   gin.H{"default": errorResponse},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if rt.role != 0 {
+			operation["description"] = "Requires the " + rt.role.String() + " role when authentication is enabled."
+			operation["security"] = []gin.H{{"bearerAuth": []string{}}, {"apiKeyHeader": []string{}}}
+		}
+		if rt.request != nil {
+			operation["requestBody"] = gin.H{
+				"required": true,
+				"content":  gin.H{"application/json": gin.H{"schema": schemas.schemaOf(reflect.TypeOf(rt.request))}},
+			}
+		}
+
+		responses := operation["responses"].(gin.H)
+		switch {
+		case rt.contentType != "":
+			responses["200"] = gin.H{
+				"description": "Download",
+				"content":     gin.H{rt.contentType: gin.H{"schema": gin.H{"type": "string", "format": "binary"}}},
+			}
+		case rt.response != nil:
+			responses["200"] = gin.H{
+				"description": "OK",
+				"content":     gin.H{"application/json": gin.H{"schema": schemas.schemaOf(reflect.TypeOf(rt.response))}},
+			}
+		default:
+			responses["204"] = gin.H{"description": "No Content"}
+		}
+
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = operation
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":       "HTTP Agent API",
+			"version":     apiVersion,
+			"description": "Executes HTTP requests and analyzes the responses with an LLM.",
+		},
+		"servers": []gin.H{{"url": APIPrefix}},
+		"paths":   paths,
+		"components": gin.H{
+			"schemas": schemas,
+			"securitySchemes": gin.H{
+				"bearerAuth":   gin.H{"type": "http", "scheme": "bearer", "description": "API key or OIDC access token"},
+				"apiKeyHeader": gin.H{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+}
+
+// openAPIPath converts a Gin path to an OpenAPI path and lists its path parameters
+func openAPIPath(path string) (string, []gin.H) {
+	var parameters []gin.H
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			parameters = append(parameters, gin.H{
+				"name": name, "in": "path", "required": true, "schema": gin.H{"type": "string"},
+			})
+		}
+	}
+	return strings.Join(segments, "/"), parameters
+}
+
+// queryParameters lists the query parameters bound by a struct's form tags
+func queryParameters(t reflect.Type) []gin.H {
+	var parameters []gin.H
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		parameters = append(parameters, gin.H{
+			"name": name, "in": "query", "schema": schemaRegistry{}.schemaOf(field.Type),
+		})
+	}
+	return parameters
+}
+
+// operationID derives a stable operation ID from the handler name, e.g. handleListHistory -> listHistory
+func operationID(rt route) string {
+	name := runtimeName(rt.handler)
+	name = strings.TrimSuffix(name[strings.LastIndex(name, ".")+1:], "-fm")
+	name, ok := strings.CutPrefix(name, "handle")
+	if !ok || name == "" {
+		return strings.ToLower(rt.method) + strings.ReplaceAll(rt.path, "/", "_")
+	}
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// schemaRegistry collects the component schemas of named struct types
+type schemaRegistry gin.H
+
+// timeType and durationType get dedicated schemas instead of their struct and integer forms
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemaOf returns the JSON schema of a Go type, registering named structs as components
+func (r schemaRegistry) schemaOf(t reflect.Type) gin.H {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return gin.H{"type": "string", "format": "date-time"}
+	case t == durationType:
+		return gin.H{"type": "integer", "format": "int64", "description": "Nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return gin.H{"type": "string", "format": "byte"}
+		}
+		return gin.H{"type": "array", "items": r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		if _, ok := r[t.Name()]; !ok {
+			r[t.Name()] = gin.H{} // Placeholder for recursive types
+			r[t.Name()] = r.structSchema(t)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return gin.H{} // Any value
+	}
+}
+
+// structSchema returns the object schema of a struct from its JSON tags
+func (r schemaRegistry) structSchema(t reflect.Type) gin.H {
+	properties := gin.H{}
+	var required []string
+	r.addProperties(t, properties, &required)
+
+	schema := gin.H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addProperties adds the JSON fields of a struct, flattening embedded structs
+func (r schemaRegistry) addProperties(t reflect.Type, properties gin.H, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.addProperties(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = r.schemaOf(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") && !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// runtimeName returns the fully qualified name of a handler function
+func runtimeName(handler gin.HandlerFunc) string {
+	if fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return ""
+}
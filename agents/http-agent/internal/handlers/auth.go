@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/audit"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the Gin context key of the authenticated caller
+const principalKey = "principal"
+
+// authorize authenticates the caller with a bearer credential (or X-API-Key header) and
+// requires at least the given role
+func (h *Handler) authorize(role auth.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		credential := c.GetHeader("X-API-Key")
+		if scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			credential = strings.TrimSpace(token)
+		}
+
+		principal, err := h.auth.Authenticate(c.Request.Context(), credential)
+		if err != nil {
+			if !errors.Is(err, auth.ErrUnauthenticated) || credential != "" {
+				slog.WarnContext(c.Request.Context(), "authentication failed", "error", err)
+			}
+			if h.limitFailedAuth(c) {
+				return
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="http-agent"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Authentication required: send an API key or access token as a Bearer credential",
+			})
+			return
+		}
+
+		c.Set(principalKey, principal)
+		c.Request = c.Request.WithContext(audit.WithPrincipal(c.Request.Context(), principal.Name))
+
+		if principal.Role < role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "The " + role.String() + " role is required (you have " + principal.Role.String() + ")",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/audit"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/auth"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/telemetry"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestLogger assigns each inbound call a request ID, reusing a valid X-Request-ID sent by the
+// caller, stores it and the caller (for the audit log) in the request context, returns the ID in the
+// response headers and logs the call once it completes
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(telemetry.RequestIDHeader)
+		if !telemetry.ValidRequestID(id) {
+			id = telemetry.NewRequestID()
+		}
+		ctx := telemetry.WithRequestID(c.Request.Context(), id)
+		ctx = audit.WithActor(ctx, audit.Actor{Source: audit.SourceAPI, ClientIP: c.ClientIP(), UserAgent: c.Request.UserAgent()})
+		c.Request = c.Request.WithContext(ctx)
+		c.Header(telemetry.RequestIDHeader, id)
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", id))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes", c.Writer.Size()),
+		}
+		if principal, ok := c.Get(principalKey); ok {
+			attrs = append(attrs, slog.String("principal", principal.(*auth.Principal).Name))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.Log(c.Request.Context(), level, "request", attrs...)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/agent"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/auth"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// busyRetryAfter is the Retry-After (seconds) sent when a concurrency cap is reached
+const busyRetryAfter = 1
+
+// RateLimiter limits the API calls of each client with a token bucket
+type RateLimiter struct {
+	mu        sync.Mutex
+	rate      float64 // Tokens added per second
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket holds the tokens left to a client
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter creates the per-client rate limiter, or returns nil when the limit is disabled
+func NewRateLimiter(config *models.LimitsConfig) *RateLimiter {
+	if config.RequestsPerMinute <= 0 {
+		return nil
+	}
+	burst := config.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:    float64(config.RequestsPerMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// allow takes a token from a client's bucket, or returns how long until one is available
+func (l *RateLimiter) allow(client string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+}
+
+// sweep forgets clients idle long enough for their bucket to refill, at most once a minute
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, b := range l.buckets {
+		if now.Sub(b.updated) >= refill {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// rateLimit refuses calls beyond the client's rate with 429 Too Many Requests. Authenticated
+// callers are limited by identity, others by IP address
+func (h *Handler) rateLimit(c *gin.Context) {
+	client := "ip:" + c.ClientIP()
+	if principal, ok := c.Get(principalKey); ok {
+		client = "principal:" + principal.(*auth.Principal).Name
+	}
+
+	if wait, ok := h.limiter.allow(client, time.Now()); !ok {
+		rejectRateLimited(c, wait)
+		return
+	}
+
+	c.Next()
+}
+
+// limitFailedAuth charges a rejected credential to the caller's IP address, so guessing
+// credentials is rate limited too; it reports whether the caller is out of tokens
+func (h *Handler) limitFailedAuth(c *gin.Context) bool {
+	if h.limiter == nil {
+		return false
+	}
+	if wait, ok := h.limiter.allow("ip:"+c.ClientIP(), time.Now()); !ok {
+		rejectRateLimited(c, wait)
+		return true
+	}
+	return false
+}
+
+// rejectRateLimited answers 429 Too Many Requests with the wait until the next token
+func rejectRateLimited(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+		"error": "Rate limit exceeded, retry in " + strconv.Itoa(seconds) + "s",
+	})
+}
+
+// rejectBusy answers 429 Too Many Requests when a concurrency cap was reached, reporting
+// whether it did
+func rejectBusy(c *gin.Context, err error) bool {
+	if !errors.Is(err, agent.ErrBusy) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(busyRetryAfter))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": err.Error(),
+	})
+	return true
+}
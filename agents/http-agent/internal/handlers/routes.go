@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/auth"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// APIPrefix is the base path of the current API version
+const APIPrefix = "/api/v1"
+
+// legacyAPIPrefix serves the unversioned routes kept for existing clients
+const legacyAPIPrefix = "/api"
+
+// route describes an API endpoint, both for registration and for the OpenAPI document
+type route struct {
+	method      string
+	path        string // Relative to the API prefix, in Gin syntax
+	handler     gin.HandlerFunc
+	role        auth.Role // Least privileged role allowed to call the endpoint; zero for public endpoints
+	tag         string
+	summary     string
+	query       any    // Struct bound from the query string, nil without parameters
+	request     any    // JSON request body, nil without one
+	response    any    // JSON body of the successful response, nil for 204 No Content
+	contentType string // Content type of non-JSON (download) responses
+}
+
+// routes lists the API endpoints
+func (h *Handler) routes() []route {
+	return []route{
+		{method: http.MethodPost, path: "/request", handler: h.handleRequest, role: auth.RoleOperator, tag: "Requests",
+			summary: "Execute a request and analyze the response",
+			request: models.RequestConfig{}, response: models.ResultResponse{}},
+		{method: http.MethodPost, path: "/request/build", handler: h.handleBuildRequest, role: auth.RoleOperator, tag: "Requests",
+			summary: "Draft a request from a natural-language description",
+			request: models.BuildRequestRequest{}, response: models.BuildRequestResponse{}},
+		{method: http.MethodPost, path: "/workflows/run", handler: h.handleRunWorkflow, role: auth.RoleOperator, tag: "Requests",
+			summary: "Run a chain of requests and summarize the flow",
+			request: models.Workflow{}, response: models.WorkflowResult{}},
+		{method: http.MethodPost, path: "/loadtest", handler: h.handleLoadTest, role: auth.RoleOperator, tag: "Requests",
+			summary: "Run a load test against an allowlisted host",
+			request: models.LoadTestRequest{}, response: models.LoadTestResult{}},
+
+		{method: http.MethodGet, path: "/sessions/:id", handler: h.handleGetSession, role: auth.RoleViewer, tag: "Sessions",
+			summary: "Get a conversation session", response: models.Session{}},
+		{method: http.MethodPost, path: "/sessions/:id/messages", handler: h.handleFollowUp, role: auth.RoleOperator, tag: "Sessions",
+			summary: "Ask a follow-up question about a response",
+			request: models.FollowUpRequest{}, response: models.FollowUpResponse{}},
+		{method: http.MethodPut, path: "/sessions/:id/model", handler: h.handleSetSessionModel, role: auth.RoleOperator, tag: "Sessions",
+			summary: "Switch the LLM provider and model answering follow-up questions",
+			request: models.ModelSelection{}, response: models.Session{}},
+		{method: http.MethodDelete, path: "/sessions/:id", handler: h.handleDeleteSession, role: auth.RoleOperator, tag: "Sessions",
+			summary: "Discard a conversation session"},
+
+		{method: http.MethodGet, path: "/history", handler: h.handleListHistory, role: auth.RoleViewer, tag: "History",
+			summary: "List persisted requests",
+			query:   models.HistoryFilter{}, response: models.HistoryListResponse{}},
+		{method: http.MethodGet, path: "/history/:id", handler: h.handleGetHistory, role: auth.RoleViewer, tag: "History",
+			summary: "Get a persisted request with its result", response: models.HistoryEntryResponse{}},
+		{method: http.MethodDelete, path: "/history/:id", handler: h.handleDeleteHistory, role: auth.RoleAdmin, tag: "History",
+			summary: "Delete a persisted request"},
+		{method: http.MethodPost, path: "/history/diff", handler: h.handleDiffHistory, role: auth.RoleOperator, tag: "History",
+			summary: "Compare two stored responses, or a stored response with a fresh run",
+			request: models.DiffRequest{}, response: models.ResponseDiff{}},
+		{method: http.MethodPost, path: "/history/:id/query", handler: h.handleQueryHistory, role: auth.RoleOperator, tag: "History",
+			summary: "Query a stored response body with JSONPath or jq-style expressions",
+			request: models.QueryRequest{}, response: models.QueryResponse{}},
+		{method: http.MethodGet, path: "/har/export", handler: h.handleExportHAR, role: auth.RoleViewer, tag: "History",
+			summary: "Export persisted requests as a HAR file",
+			query:   models.HistoryFilter{}, response: models.HAR{}},
+		{method: http.MethodPost, path: "/har/import", handler: h.handleImportHAR, role: auth.RoleOperator, tag: "History",
+			summary: "Import and optionally replay a HAR file",
+			request: models.HARImportRequest{}, response: models.HARImportResponse{}},
+
+		{method: http.MethodGet, path: "/audit", handler: h.handleListAudit, role: auth.RoleAdmin, tag: "Audit",
+			summary: "List audited outbound requests",
+			query:   models.AuditFilter{}, response: models.AuditListResponse{}},
+		{method: http.MethodGet, path: "/audit/export", handler: h.handleExportAudit, role: auth.RoleAdmin, tag: "Audit",
+			summary: "Export the audit log as JSON Lines or CSV",
+			query:   models.AuditFilter{}, contentType: "application/x-ndjson"},
+
+		{method: http.MethodPost, path: "/openapi", handler: h.handleLoadOpenAPI, role: auth.RoleAdmin, tag: "OpenAPI specs",
+			summary: "Load a target OpenAPI spec from a URL or inline content",
+			request: models.OpenAPILoadRequest{}, response: models.OpenAPISpec{}},
+		{method: http.MethodGet, path: "/openapi", handler: h.handleListOpenAPI, role: auth.RoleViewer, tag: "OpenAPI specs",
+			summary: "List the loaded target specs", response: models.OpenAPISpecListResponse{}},
+		{method: http.MethodGet, path: "/openapi/:id", handler: h.handleGetOpenAPI, role: auth.RoleViewer, tag: "OpenAPI specs",
+			summary: "Get a loaded target spec with its operations", response: models.OpenAPISpec{}},
+		{method: http.MethodDelete, path: "/openapi/:id", handler: h.handleDeleteOpenAPI, role: auth.RoleAdmin, tag: "OpenAPI specs",
+			summary: "Unload a target spec"},
+
+		{method: http.MethodPost, path: "/monitors", handler: h.handleCreateMonitor, role: auth.RoleAdmin, tag: "Monitors",
+			summary: "Schedule a request to run periodically",
+			request: models.Monitor{}, response: models.Monitor{}},
+		{method: http.MethodGet, path: "/monitors", handler: h.handleListMonitors, role: auth.RoleViewer, tag: "Monitors",
+			summary: "List monitors with their latest run", response: models.MonitorListResponse{}},
+		{method: http.MethodGet, path: "/monitors/:id", handler: h.handleGetMonitor, role: auth.RoleViewer, tag: "Monitors",
+			summary: "Get a monitor with its recent runs", response: models.Monitor{}},
+		{method: http.MethodDelete, path: "/monitors/:id", handler: h.handleDeleteMonitor, role: auth.RoleAdmin, tag: "Monitors",
+			summary: "Unschedule a monitor"},
+		{method: http.MethodPost, path: "/monitors/:id/run", handler: h.handleRunMonitor, role: auth.RoleOperator, tag: "Monitors",
+			summary: "Run a monitor immediately", response: models.MonitorRun{}},
+
+		{method: http.MethodGet, path: "/profiles", handler: h.handleListProfiles, role: auth.RoleViewer, tag: "Configuration",
+			summary: "List the analysis profiles", response: models.ProfileListResponse{}},
+		{method: http.MethodGet, path: "/models", handler: h.handleListModels, role: auth.RoleViewer, tag: "Configuration",
+			summary: "List LLM providers with their available models", response: models.ModelListResponse{}},
+		{method: http.MethodGet, path: "/environments", handler: h.handleListEnvironments, role: auth.RoleViewer, tag: "Configuration",
+			summary: "List named environments with secrets masked", response: models.EnvironmentListResponse{}},
+		{method: http.MethodGet, path: "/environments/:name", handler: h.handleGetEnvironment, role: auth.RoleViewer, tag: "Configuration",
+			summary: "Get a named environment with secrets masked", response: models.Environment{}},
+		{method: http.MethodPut, path: "/environments/:name", handler: h.handleSaveEnvironment, role: auth.RoleAdmin, tag: "Configuration",
+			summary: "Create or replace a named environment",
+			request: models.Environment{}, response: models.Environment{}},
+		{method: http.MethodDelete, path: "/environments/:name", handler: h.handleDeleteEnvironment, role: auth.RoleAdmin, tag: "Configuration",
+			summary: "Delete a named environment"},
+
+		{method: http.MethodGet, path: "/cookiejars", handler: h.handleListCookieJars, role: auth.RoleOperator, tag: "Cookies",
+			summary: "List cookie jars", response: models.CookieJarListResponse{}},
+		{method: http.MethodGet, path: "/cookiejars/:name", handler: h.handleGetCookieJar, role: auth.RoleOperator, tag: "Cookies",
+			summary: "Get the cookies stored in a jar", response: models.CookieJarResponse{}},
+		{method: http.MethodDelete, path: "/cookiejars/:name", handler: h.handleDeleteCookieJar, role: auth.RoleOperator, tag: "Cookies",
+			summary: "Clear a cookie jar"},
+		{method: http.MethodPut, path: "/cookiejars/:name/cookies", handler: h.handleSetCookie, role: auth.RoleOperator, tag: "Cookies",
+			summary: "Add or replace a cookie in a jar",
+			request: models.Cookie{}, response: models.Cookie{}},
+		{method: http.MethodDelete, path: "/cookiejars/:name/cookies/:cookie", handler: h.handleDeleteCookie, role: auth.RoleOperator, tag: "Cookies",
+			summary: "Remove a cookie from a jar", query: models.CookieSelector{}},
+
+		{method: http.MethodGet, path: "/health", handler: h.handleHealth, tag: "Service",
+			summary: "Report the service status", response: models.HealthResponse{}},
+	}
+}
+
+// registerRoutes registers the API endpoints under the versioned prefix and the deprecated
+// unversioned alias
+func (h *Handler) registerRoutes(r *gin.Engine) {
+	v1 := r.Group(APIPrefix)
+	legacy := r.Group(legacyAPIPrefix, deprecated)
+	for _, rt := range h.routes() {
+		var handlers []gin.HandlerFunc
+		if h.auth != nil && rt.role != 0 {
+			handlers = append(handlers, h.authorize(rt.role))
+		}
+		if h.limiter != nil && rt.role != 0 {
+			handlers = append(handlers, h.rateLimit)
+		}
+		handlers = append(handlers, rt.handler)
+		v1.Handle(rt.method, rt.path, handlers...)
+		if rt.path != "/health" {
+			legacy.Handle(rt.method, rt.path, handlers...)
+		}
+	}
+
+	v1.GET("/openapi.json", h.handleAPIDocument)
+	v1.GET("/docs", h.handleAPIDocs)
+}
+
+// deprecated marks responses of the unversioned routes and points clients at their successors
+func deprecated(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	c.Header("Link", "<"+APIPrefix+c.Request.URL.Path[len(legacyAPIPrefix):]+`>; rel="successor-version"`)
+	c.Next()
+}
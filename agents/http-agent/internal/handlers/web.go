@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"embed"
+	"errors"
 	"html/template"
 	"io/fs"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/agent"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/auth"
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/history"
 	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
 	"github.com/gin-gonic/gin"
 )
@@ -21,12 +25,15 @@ var staticFS embed.FS
 
 // Handler handles HTTP requests
 type Handler struct {
-	agent *agent.HTTPAgent
+	agent   *agent.HTTPAgent
+	auth    *auth.Authenticator // nil leaves the API open
+	limiter *RateLimiter        // nil when API calls are not rate limited
 }
 
-// NewHandler creates a new handler
-func NewHandler(ag *agent.HTTPAgent) *Handler {
-	return &Handler{agent: ag}
+// NewHandler creates a new handler; authenticator and limiter may be nil to disable
+// authentication and rate limiting
+func NewHandler(ag *agent.HTTPAgent, authenticator *auth.Authenticator, limiter *RateLimiter) *Handler {
+	return &Handler{agent: ag, auth: authenticator, limiter: limiter}
 }
 
 // SetupRoutes configures the Gin routes
@@ -47,7 +54,7 @@ func (h *Handler) SetupRoutes(r *gin.Engine) {
 
 	// Routes
 	r.GET("/", h.handleIndex)
-	r.POST("/api/request", h.handleRequest)
+	h.registerRoutes(r)
 	r.GET("/health", h.handleHealth)
 }
 
@@ -76,6 +83,13 @@ func (h *Handler) handleRequest(c *gin.Context) {
 		return
 	}
 
+	if req.GraphQL != nil && strings.TrimSpace(req.GraphQL.Query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "GraphQL query is required",
+		})
+		return
+	}
+
 	if req.Method == "" {
 		req.Method = "GET"
 	}
@@ -85,6 +99,16 @@ func (h *Handler) handleRequest(c *gin.Context) {
 
 	// Execute request
 	result, err := h.agent.Execute(c.Request.Context(), &req)
+	if rejectBusy(c, err) {
+		return
+	}
+	if errors.Is(err, agent.ErrSpecNotFound) || errors.Is(err, agent.ErrEnvironmentNotFound) || errors.Is(err, agent.ErrUnknownProfile) || errors.Is(err, agent.ErrInvalidComparison) ||
+		errors.Is(err, agent.ErrInvalidAuth) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to execute request: " + err.Error(),
@@ -92,35 +116,737 @@ func (h *Handler) handleRequest(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, resultResponse(result))
+}
+
+// handleBuildRequest drafts a request from a natural-language description without executing it
+func (h *Handler) handleBuildRequest(c *gin.Context) {
+	var req models.BuildRequestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	reqConfig, err := h.agent.BuildRequest(c.Request.Context(), req.Description)
+	if rejectBusy(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.BuildRequestResponse{Request: reqConfig})
+}
+
+// handleRunWorkflow executes a chain of requests and summarizes the flow
+func (h *Handler) handleRunWorkflow(c *gin.Context) {
+	var workflow models.Workflow
+	if err := c.ShouldBindJSON(&workflow); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	for i, step := range workflow.Steps {
+		if step.Request.URL == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "URL is required for step " + strconv.Itoa(i+1),
+			})
+			return
+		}
+	}
+
+	result, err := h.agent.RunWorkflow(c.Request.Context(), &workflow)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// resultResponse builds the API representation of an analysis result
+func resultResponse(result *models.AnalysisResult) *models.ResultResponse {
+	resp := &models.ResultResponse{
+		Error:          result.Error,
+		DNSDiagnostics: result.DNSDiagnostics,
+		SSLDiagnostics: result.SSLDiagnostics,
+		Reachability:   result.Reachability,
+		SSLVerified:    result.SSLVerified,
+		HistoryID:      result.HistoryID,
+		Assertions:     result.Assertions,
+		Passed:         result.Passed,
+	}
+
 	// Add color and description for status code
 	if result.Response != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"request":          result.Request,
-			"response":         result.Response,
-			"analysis":         result.Analysis,
-			"formatted_body":   result.FormattedBody,
-			"request_duration": result.RequestDuration,
-			"status_color":     agent.GetStatusCodeColor(result.Response.StatusCode),
-			"status_desc":      agent.GetStatusCodeDescription(result.Response.StatusCode),
-			"dns_diagnostics":  result.DNSDiagnostics,
-			"ssl_diagnostics":  result.SSLDiagnostics,
-			"ssl_verified":     result.SSLVerified,
-			"error":            result.Error,
+		resp.Request = result.Request
+		resp.Response = result.Response
+		resp.Analysis = result.Analysis
+		resp.FormattedBody = result.FormattedBody
+		resp.RequestDuration = result.RequestDuration
+		resp.StatusColor = agent.GetStatusCodeColor(result.Response.StatusCode)
+		resp.StatusDesc = agent.GetStatusCodeDescription(result.Response.StatusCode)
+		resp.SessionID = result.SessionID
+		resp.Investigation = result.Investigation
+		resp.SecurityReport = result.SecurityReport
+		resp.Comparison = result.Comparison
+	}
+
+	return resp
+}
+
+// handleGetSession returns a conversation session
+func (h *Handler) handleGetSession(c *gin.Context) {
+	session, err := h.agent.GetSession(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// handleFollowUp answers a follow-up question within a session
+func (h *Handler) handleFollowUp(c *gin.Context) {
+	var req models.FollowUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	session, err := h.agent.FollowUp(c.Request.Context(), c.Param("id"), req.Question)
+	if rejectBusy(c, err) {
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, agent.ErrSessionNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.FollowUpResponse{
+		SessionID: session.ID,
+		Answer:    session.Messages[len(session.Messages)-1].Content,
+		Messages:  session.Messages,
+	})
+}
+
+// handleSetSessionModel switches the LLM provider and model answering a session's follow-ups
+func (h *Handler) handleSetSessionModel(c *gin.Context) {
+	var req models.ModelSelection
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	session, err := h.agent.SetSessionModel(c.Request.Context(), c.Param("id"), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, agent.ErrSessionNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, agent.ErrInvalidModel):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, session)
+}
+
+// handleDeleteSession discards a conversation session
+func (h *Handler) handleDeleteSession(c *gin.Context) {
+	if err := h.agent.DeleteSession(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleListHistory lists persisted requests, optionally filtered by URL and status
+func (h *Handler) handleListHistory(c *gin.Context) {
+	var filter models.HistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	entries, err := h.agent.ListHistory(c.Request.Context(), &filter)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.HistoryListResponse{Entries: entries})
+}
+
+// handleGetHistory returns a persisted request in the same shape as /api/request
+func (h *Handler) handleGetHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid history ID",
+		})
+		return
+	}
+
+	entry, err := h.agent.GetHistoryEntry(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.HistoryEntryResponse{
+		ID:        entry.ID,
+		CreatedAt: entry.CreatedAt,
+		Result:    resultResponse(entry.Result),
+	})
+}
+
+// handleDeleteHistory removes a persisted request
+func (h *Handler) handleDeleteHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid history ID",
+		})
+		return
+	}
+
+	if err := h.agent.DeleteHistoryEntry(c.Request.Context(), id); err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleExportHAR exports request history entries as a HAR file
+func (h *Handler) handleExportHAR(c *gin.Context) {
+	var filter models.HistoryFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	har, err := h.agent.ExportHAR(c.Request.Context(), &filter)
+	if err != nil {
+		c.JSON(historyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="http-agent.har"`)
+	c.JSON(http.StatusOK, har)
+}
+
+// handleImportHAR parses an uploaded HAR file and optionally replays its entries
+func (h *Handler) handleImportHAR(c *gin.Context) {
+	var req models.HARImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	requests, results, err := h.agent.ImportHAR(c.Request.Context(), &req)
+	if rejectBusy(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	responses := make([]models.ResultResponse, 0, len(results))
+	for _, result := range results {
+		responses = append(responses, *resultResponse(result))
+	}
+
+	c.JSON(http.StatusOK, models.HARImportResponse{
+		Requests: requests,
+		Results:  responses,
+	})
+}
+
+// handleLoadOpenAPI loads an OpenAPI spec from a URL or inline content
+func (h *Handler) handleLoadOpenAPI(c *gin.Context) {
+	var req models.OpenAPILoadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	spec, err := h.agent.LoadOpenAPISpec(c.Request.Context(), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, spec)
+}
+
+// handleListOpenAPI lists loaded OpenAPI specs
+func (h *Handler) handleListOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, models.OpenAPISpecListResponse{Specs: h.agent.ListOpenAPISpecs()})
+}
+
+// handleGetOpenAPI returns a loaded spec with its operations
+func (h *Handler) handleGetOpenAPI(c *gin.Context) {
+	spec, err := h.agent.GetOpenAPISpec(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, spec)
+}
+
+// handleDeleteOpenAPI unloads a spec
+func (h *Handler) handleDeleteOpenAPI(c *gin.Context) {
+	if err := h.agent.DeleteOpenAPISpec(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleLoadTest runs a load test against an allowlisted host
+func (h *Handler) handleLoadTest(c *gin.Context) {
+	var loadReq models.LoadTestRequest
+	if err := c.ShouldBindJSON(&loadReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.agent.RunLoadTest(c.Request.Context(), &loadReq)
+	if rejectBusy(c, err) {
+		return
+	}
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, agent.ErrLoadTestDisabled) || errors.Is(err, agent.ErrLoadTestNotAllowed) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleCreateMonitor registers a request to run on a schedule
+func (h *Handler) handleCreateMonitor(c *gin.Context) {
+	var monitor models.Monitor
+	if err := c.ShouldBindJSON(&monitor); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	created, err := h.agent.CreateMonitor(&monitor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+// handleListMonitors lists monitors with their latest run
+func (h *Handler) handleListMonitors(c *gin.Context) {
+	c.JSON(http.StatusOK, models.MonitorListResponse{Monitors: h.agent.ListMonitors()})
+}
+
+// handleGetMonitor returns a monitor with its recent runs
+func (h *Handler) handleGetMonitor(c *gin.Context) {
+	monitor, err := h.agent.GetMonitor(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, monitor)
+}
+
+// handleDeleteMonitor unschedules a monitor
+func (h *Handler) handleDeleteMonitor(c *gin.Context) {
+	if err := h.agent.DeleteMonitor(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleRunMonitor runs a monitor immediately
+func (h *Handler) handleRunMonitor(c *gin.Context) {
+	run, err := h.agent.RunMonitor(c.Request.Context(), c.Param("id"))
+	if rejectBusy(c, err) {
+		return
+	}
+	if errors.Is(err, agent.ErrMonitorNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to run monitor: " + err.Error(),
 		})
-	} else {
-		c.JSON(http.StatusOK, gin.H{
-			"error":           result.Error,
-			"dns_diagnostics": result.DNSDiagnostics,
-			"ssl_diagnostics": result.SSLDiagnostics,
-			"ssl_verified":    result.SSLVerified,
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// handleListProfiles lists the analysis profiles that requests can select
+func (h *Handler) handleListProfiles(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ProfileListResponse{Profiles: h.agent.PromptProfiles()})
+}
+
+// handleListModels lists the configured LLM providers with their available models
+func (h *Handler) handleListModels(c *gin.Context) {
+	c.JSON(http.StatusOK, models.ModelListResponse{
+		Providers: h.agent.ListModels(c.Request.Context()),
+	})
+}
+
+// handleListEnvironments lists the named environments with secrets masked
+func (h *Handler) handleListEnvironments(c *gin.Context) {
+	c.JSON(http.StatusOK, models.EnvironmentListResponse{Environments: h.agent.ListEnvironments()})
+}
+
+// handleGetEnvironment returns a named environment with secrets masked
+func (h *Handler) handleGetEnvironment(c *gin.Context) {
+	env, err := h.agent.GetEnvironment(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, env)
+}
+
+// handleSaveEnvironment creates or replaces a named environment
+func (h *Handler) handleSaveEnvironment(c *gin.Context) {
+	var env models.Environment
+	if err := c.ShouldBindJSON(&env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
 		})
+		return
+	}
+	env.Name = c.Param("name")
+
+	saved, err := h.agent.SaveEnvironment(&env)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// handleDeleteEnvironment removes a named environment
+func (h *Handler) handleDeleteEnvironment(c *gin.Context) {
+	if err := h.agent.DeleteEnvironment(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleListCookieJars lists cookie jars with their cookie counts
+func (h *Handler) handleListCookieJars(c *gin.Context) {
+	c.JSON(http.StatusOK, models.CookieJarListResponse{CookieJars: h.agent.ListCookieJars()})
+}
+
+// handleGetCookieJar returns the cookies stored in a jar
+func (h *Handler) handleGetCookieJar(c *gin.Context) {
+	cookies, err := h.agent.GetCookieJar(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.CookieJarResponse{
+		Name:    c.Param("name"),
+		Cookies: cookies,
+	})
+}
+
+// handleDeleteCookieJar clears a cookie jar
+func (h *Handler) handleDeleteCookieJar(c *gin.Context) {
+	if err := h.agent.DeleteCookieJar(c.Param("name")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleSetCookie adds or replaces a cookie in a jar
+func (h *Handler) handleSetCookie(c *gin.Context) {
+	var cookie models.Cookie
+	if err := c.ShouldBindJSON(&cookie); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	saved, err := h.agent.SetCookie(c.Param("name"), &cookie)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// handleDeleteCookie removes a cookie identified by name, domain and path from a jar
+func (h *Handler) handleDeleteCookie(c *gin.Context) {
+	var selector models.CookieSelector
+	if err := c.ShouldBindQuery(&selector); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	err := h.agent.DeleteCookie(c.Param("name"), selector.Domain, selector.Path, c.Param("cookie"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// handleDiffHistory compares two stored responses, or a stored response with a fresh run
+func (h *Handler) handleDiffHistory(c *gin.Context) {
+	var diffReq models.DiffRequest
+	if err := c.ShouldBindJSON(&diffReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	diff, err := h.agent.DiffResponses(c.Request.Context(), &diffReq)
+	if rejectBusy(c, err) {
+		return
+	}
+	if err != nil {
+		status := historyErrorStatus(err)
+		if errors.Is(err, agent.ErrNoResponse) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// handleQueryHistory extracts values from a stored response body with JSONPath or jq-style expressions
+func (h *Handler) handleQueryHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid history ID",
+		})
+		return
+	}
+
+	var queryReq models.QueryRequest
+	if err := c.ShouldBindJSON(&queryReq); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format: " + err.Error(),
+		})
+		return
+	}
+
+	result, err := h.agent.QueryHistory(c.Request.Context(), id, &queryReq)
+	if err != nil {
+		status := historyErrorStatus(err)
+		if errors.Is(err, agent.ErrNoResponse) || errors.Is(err, agent.ErrInvalidQuery) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// handleListAudit returns the most recent audited outbound requests
+func (h *Handler) handleListAudit(c *gin.Context) {
+	var filter models.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	entries, err := h.agent.ListAudit(&filter)
+	if err != nil {
+		c.JSON(auditErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.AuditListResponse{
+		Entries: entries,
+		Count:   len(entries),
+	})
+}
+
+// handleExportAudit streams the audit log as a JSON Lines or CSV download
+func (h *Handler) handleExportAudit(c *gin.Context) {
+	var filter models.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid query parameters: " + err.Error(),
+		})
+		return
+	}
+
+	contentType, filename := "application/x-ndjson", "http-agent-audit.jsonl"
+	switch strings.ToLower(filter.Format) {
+	case "", "jsonl":
+	case "csv":
+		contentType, filename = "text/csv", "http-agent-audit.csv"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid format (use jsonl or csv)",
+		})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if err := h.agent.ExportAudit(c.Writer, &filter); err != nil {
+		if c.Writer.Written() {
+			// The download is already under way and ends early
+			_ = c.Error(err)
+			return
+		}
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.JSON(auditErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+	}
+}
+
+// auditErrorStatus maps audit log errors to HTTP status codes
+func auditErrorStatus(err error) int {
+	if errors.Is(err, agent.ErrAuditDisabled) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// historyErrorStatus maps history errors to HTTP status codes
+func historyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, history.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, agent.ErrHistoryDisabled):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
 	}
 }
 
 // handleHealth returns health status
 func (h *Handler) handleHealth(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "healthy",
-		"service": "http-agent",
+	c.JSON(http.StatusOK, models.HealthResponse{
+		Status:  "healthy",
+		Service: "http-agent",
 	})
 }
@@ -0,0 +1,177 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver (works with CGO_ENABLED=0)
+)
+
+// ErrNotFound is returned when a history entry does not exist
+var ErrNotFound = errors.New("history entry not found")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS history (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	created_at  TIMESTAMP NOT NULL,
+	url         TEXT NOT NULL,
+	method      TEXT NOT NULL,
+	status_code INTEGER NOT NULL DEFAULT 0,
+	duration_ns INTEGER NOT NULL DEFAULT 0,
+	error       TEXT NOT NULL DEFAULT '',
+	result_json TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_history_created_at ON history(created_at);
+CREATE INDEX IF NOT EXISTS idx_history_url ON history(url);
+CREATE INDEX IF NOT EXISTS idx_history_status_code ON history(status_code);
+`
+
+// Store persists executed requests and their analysis in an embedded SQLite database
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore opens (or creates) the SQLite history database at the given path
+func NewStore(path string) (*Store, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database: %w", err)
+	}
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history database: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Save records an executed request and returns the new entry ID
+func (s *Store) Save(ctx context.Context, result *models.AnalysisResult) (int64, error) {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	createdAt := time.Now().UTC()
+	var statusCode int
+	var duration time.Duration
+	if result.Response != nil {
+		createdAt = result.Response.Timestamp.UTC()
+		statusCode = result.Response.StatusCode
+		duration = result.Response.Duration
+	}
+
+	res, err := s.db.ExecContext(ctx,
+		`INSERT INTO history (created_at, url, method, status_code, duration_ns, error, result_json)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		createdAt, result.Request.URL, result.Request.Method, statusCode, int64(duration), result.Error, string(resultJSON),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save history entry: %w", err)
+	}
+
+	return res.LastInsertId()
+}
+
+// List returns history entries (without full results), newest first
+func (s *Store) List(ctx context.Context, filter *models.HistoryFilter) ([]models.HistoryEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.URL != "" {
+		conditions = append(conditions, "url LIKE ?")
+		args = append(args, "%"+filter.URL+"%")
+	}
+	if filter.StatusCode != 0 {
+		conditions = append(conditions, "status_code = ?")
+		args = append(args, filter.StatusCode)
+	}
+
+	query := "SELECT id, created_at, url, method, status_code, duration_ns, error FROM history"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+	query += " ORDER BY id DESC LIMIT ? OFFSET ?"
+	args = append(args, limit, filter.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.HistoryEntry{}
+	for rows.Next() {
+		var entry models.HistoryEntry
+		var duration int64
+		if err := rows.Scan(&entry.ID, &entry.CreatedAt, &entry.URL, &entry.Method, &entry.StatusCode, &duration, &entry.Error); err != nil {
+			return nil, fmt.Errorf("failed to read history entry: %w", err)
+		}
+		entry.Duration = time.Duration(duration)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Get returns a single history entry including the full result
+func (s *Store) Get(ctx context.Context, id int64) (*models.HistoryEntry, error) {
+	var entry models.HistoryEntry
+	var duration int64
+	var resultJSON string
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, created_at, url, method, status_code, duration_ns, error, result_json FROM history WHERE id = ?", id,
+	).Scan(&entry.ID, &entry.CreatedAt, &entry.URL, &entry.Method, &entry.StatusCode, &duration, &entry.Error, &resultJSON)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history entry: %w", err)
+	}
+
+	entry.Duration = time.Duration(duration)
+	if err := json.Unmarshal([]byte(resultJSON), &entry.Result); err != nil {
+		return nil, fmt.Errorf("failed to decode history entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// Delete removes a history entry
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM history WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete history entry: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
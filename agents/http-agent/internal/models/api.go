@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+// ErrorResponse is the body of every failed API call
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// ResultResponse is the API representation of an analysis result. Response-derived fields
+// are omitted when the request itself failed
+type ResultResponse struct {
+	Request         *RequestConfig             `json:"request,omitempty"`
+	Response        *Response                  `json:"response,omitempty"`
+	Analysis        string                     `json:"analysis,omitempty"`
+	FormattedBody   string                     `json:"formatted_body,omitempty"`
+	RequestDuration string                     `json:"request_duration,omitempty"`
+	StatusColor     string                     `json:"status_color,omitempty"`
+	StatusDesc      string                     `json:"status_desc,omitempty"`
+	DNSDiagnostics  *DNSDiagnostics            `json:"dns_diagnostics,omitempty"`
+	SSLDiagnostics  *SSLCertificateDiagnostics `json:"ssl_diagnostics,omitempty"`
+	Reachability    *ReachabilityReport        `json:"reachability,omitempty"`
+	SSLVerified     bool                       `json:"ssl_verified"`
+	SessionID       string                     `json:"session_id,omitempty"`
+	HistoryID       int64                      `json:"history_id,omitempty"`
+	Investigation   []InvestigationStep        `json:"investigation,omitempty"`
+	Assertions      []AssertionResult          `json:"assertions,omitempty"`
+	Passed          *bool                      `json:"passed,omitempty"`
+	SecurityReport  *SecurityReport            `json:"security_report,omitempty"`
+	Comparison      []ProviderAnswer           `json:"comparison,omitempty"`
+	Error           string                     `json:"error,omitempty"`
+}
+
+// BuildRequestResponse holds a request drafted from a description
+type BuildRequestResponse struct {
+	Request *RequestConfig `json:"request"`
+}
+
+// FollowUpResponse holds the answer to a follow-up question and the whole conversation
+type FollowUpResponse struct {
+	SessionID string        `json:"session_id"`
+	Answer    string        `json:"answer"`
+	Messages  []ChatMessage `json:"messages"`
+}
+
+// HistoryListResponse lists persisted requests, newest first
+type HistoryListResponse struct {
+	Entries []HistoryEntry `json:"entries"`
+}
+
+// HistoryEntryResponse is a persisted request with its full result
+type HistoryEntryResponse struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Result    *ResultResponse `json:"result"`
+}
+
+// HARImportResponse holds the requests parsed from a HAR file and, when replayed, their results
+type HARImportResponse struct {
+	Requests []RequestConfig  `json:"requests"`
+	Results  []ResultResponse `json:"results"`
+}
+
+// OpenAPISpecListResponse lists the loaded OpenAPI specs
+type OpenAPISpecListResponse struct {
+	Specs []OpenAPISpec `json:"specs"`
+}
+
+// MonitorListResponse lists the monitors with their latest run
+type MonitorListResponse struct {
+	Monitors []Monitor `json:"monitors"`
+}
+
+// ModelListResponse lists the configured LLM providers with their available models
+type ModelListResponse struct {
+	Providers []ProviderModels `json:"providers"`
+}
+
+// ProfileListResponse lists the analysis profile names
+type ProfileListResponse struct {
+	Profiles []string `json:"profiles"`
+}
+
+// EnvironmentListResponse lists the named environments
+type EnvironmentListResponse struct {
+	Environments []Environment `json:"environments"`
+}
+
+// CookieJarListResponse lists the cookie jars
+type CookieJarListResponse struct {
+	CookieJars []CookieJarSummary `json:"cookie_jars"`
+}
+
+// CookieJarResponse holds the cookies stored in a jar
+type CookieJarResponse struct {
+	Name    string   `json:"name"`
+	Cookies []Cookie `json:"cookies"`
+}
+
+// CookieSelector identifies a cookie within a jar together with its name
+type CookieSelector struct {
+	Domain string `form:"domain"`
+	Path   string `form:"path"`
+}
+
+// AuditListResponse lists audited outbound requests, newest first
+type AuditListResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Count   int          `json:"count"`
+}
+
+// HealthResponse reports the service status
+type HealthResponse struct {
+	Status  string `json:"status"`
+	Service string `json:"service"`
+}
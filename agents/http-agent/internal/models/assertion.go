@@ -0,0 +1,18 @@
+package models
+
+// Assertion is a check applied to a response, such as status eq 200 or $.data.id exists
+type Assertion struct {
+	// Source is status, latency (milliseconds), body, header:Name or a JSONPath ($.data.id)
+	Source string `json:"source"`
+	// Operator is eq (default), ne, lt, lte, gt, gte, contains, matches, exists or not_exists
+	Operator string `json:"operator,omitempty"`
+	Expected string `json:"expected,omitempty"`
+}
+
+// AssertionResult is the outcome of an assertion
+type AssertionResult struct {
+	Assertion
+	Passed  bool   `json:"passed"`
+	Actual  string `json:"actual,omitempty"`
+	Message string `json:"message,omitempty"` // Why the assertion failed
+}
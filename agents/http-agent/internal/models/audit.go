@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// AuditEntry records one outbound request made by the agent
+type AuditEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	RequestID string    `json:"request_id,omitempty"` // X-Request-ID of the API call or monitor run that caused it
+	Source    string    `json:"source"`               // api or monitor
+	ClientIP  string    `json:"client_ip,omitempty"`  // API caller
+	UserAgent string    `json:"user_agent,omitempty"` // API caller
+	Principal string    `json:"principal,omitempty"`  // Authenticated API caller
+	MonitorID string    `json:"monitor_id,omitempty"`
+	Protocol  string    `json:"protocol"` // http, websocket, grpc, loadtest, tcp, icmp, traceroute or tls
+	Method    string    `json:"method"`
+	URL       string    `json:"url"` // Without query string and credentials
+	Status    int       `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Duration  int64     `json:"duration_ms"`
+	Requests  int       `json:"requests,omitempty"` // Requests sent by a load test
+}
+
+// AuditFilter selects audit entries
+type AuditFilter struct {
+	Since  time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until  time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Host   string    `form:"host"`   // Substring match on the target host
+	Format string    `form:"format"` // jsonl (default) or csv; export only
+	Limit  int       `form:"limit"`  // Most recent entries returned by the list endpoint
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Cookie is a cookie stored in a cookie jar
+type Cookie struct {
+	Name     string     `json:"name" binding:"required"`
+	Value    string     `json:"value"`
+	Domain   string     `json:"domain" binding:"required"`
+	Path     string     `json:"path"`              // Defaults to /
+	HostOnly bool       `json:"host_only"`         // Only sent to Domain itself, not its subdomains
+	Expires  *time.Time `json:"expires,omitempty"` // nil for session cookies
+	Secure   bool       `json:"secure"`
+	HttpOnly bool       `json:"http_only"`
+	SameSite string     `json:"same_site,omitempty"` // Lax, Strict or None
+}
+
+// CookieJarSummary describes a cookie jar in listings
+type CookieJarSummary struct {
+	Name    string `json:"name"`
+	Cookies int    `json:"cookies"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// DiffRequest selects two stored responses to compare
+type DiffRequest struct {
+	BaseID int64 `json:"base_id" binding:"required"` // History entry used as the reference
+	// TargetID is the history entry to compare; when omitted the base request is executed again
+	TargetID int64  `json:"target_id,omitempty"`
+	Prompt   string `json:"prompt"`
+}
+
+// DiffChange is a single difference between two responses
+type DiffChange struct {
+	Path   string `json:"path"` // JSONPath into the body or header name
+	Type   string `json:"type"` // added, removed or changed
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// ResponseDiff is the comparison of two responses to the same request
+type ResponseDiff struct {
+	BaseID       int64        `json:"base_id"`
+	TargetID     int64        `json:"target_id"`
+	StatusBefore int          `json:"status_before"`
+	StatusAfter  int          `json:"status_after"`
+	LatencyDelta string       `json:"latency_delta"` // Target minus base, e.g. +120.00ms
+	Headers      []DiffChange `json:"headers"`
+	Body         []DiffChange `json:"body"`
+	Truncated    bool         `json:"truncated,omitempty"` // More body changes than reported
+	Analysis     string       `json:"analysis"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// Environment is a named set of variables (e.g. dev, staging, prod) substituted into
+// requests through {{name}} placeholders
+type Environment struct {
+	Name      string                `json:"name" mapstructure:"name"`
+	Variables []EnvironmentVariable `json:"variables" mapstructure:"variables"`
+}
+
+// EnvironmentVariable is a single environment value; secret values are masked in
+// API responses, prompts and request history
+type EnvironmentVariable struct {
+	Name   string `json:"name" mapstructure:"name" binding:"required"`
+	Value  string `json:"value" mapstructure:"value"`
+	Secret bool   `json:"secret" mapstructure:"secret"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// LoadTestRequest runs a request repeatedly from concurrent workers
+type LoadTestRequest struct {
+	Request     RequestConfig `json:"request"`
+	Concurrency int           `json:"concurrency"` // Parallel workers
+	Requests    int           `json:"requests"`    // Total requests to send
+	Duration    int           `json:"duration"`    // Seconds; the test stops at whichever limit comes first
+	Prompt      string        `json:"prompt"`
+}
+
+// LoadTestResult summarizes a load test
+type LoadTestResult struct {
+	Request     *RequestConfig `json:"request"`
+	Concurrency int            `json:"concurrency"`
+	Requests    int            `json:"requests"`   // Requests completed or failed
+	Failures    int            `json:"failures"`   // Transport errors and 4xx/5xx responses
+	ErrorRate   float64        `json:"error_rate"` // Failures / requests
+	Elapsed     string         `json:"elapsed"`
+	Throughput  float64        `json:"throughput"`   // Requests per second
+	StatusCodes map[string]int `json:"status_codes"` // Responses per status code
+	Errors      map[string]int `json:"errors,omitempty"`
+	Latency     LatencyStats   `json:"latency"` // Over requests that received a response
+	Analysis    string         `json:"analysis"`
+}
+
+// LatencyStats describes a latency distribution
+type LatencyStats struct {
+	Min  string `json:"min"`
+	Mean string `json:"mean"`
+	P50  string `json:"p50"`
+	P90  string `json:"p90"`
+	P95  string `json:"p95"`
+	P99  string `json:"p99"`
+	Max  string `json:"max"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Monitor is a request executed on a schedule; regressions raise alerts
+type Monitor struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Schedule string        `json:"schedule" binding:"required"` // Cron expression (*/5 * * * *) or @every 1m
+	Request  RequestConfig `json:"request"`
+	// ExpectStatus is the healthy status code; by default the status of the first healthy run
+	ExpectStatus int `json:"expect_status,omitempty"`
+	// MaxLatency in milliseconds; by default a run is slow when it takes 3x the recent average
+	MaxLatency int            `json:"max_latency_ms,omitempty"`
+	Alerts     []MonitorAlert `json:"alerts,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	State      string         `json:"state"` // pending, healthy or failing
+	LastRun    *MonitorRun    `json:"last_run,omitempty"`
+	NextRun    *time.Time     `json:"next_run,omitempty"`
+	Runs       []MonitorRun   `json:"runs,omitempty"` // Recent runs, newest first; only returned for a single monitor
+}
+
+// MonitorAlert is a destination notified when a monitor starts failing or recovers
+type MonitorAlert struct {
+	Type string   `json:"type"`          // webhook, slack or email
+	URL  string   `json:"url,omitempty"` // Webhook or Slack incoming webhook URL
+	To   []string `json:"to,omitempty"`  // Email recipients
+}
+
+// MonitorRun is the outcome of a single scheduled execution
+type MonitorRun struct {
+	Time        time.Time         `json:"time"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Latency     int64             `json:"latency_ms"`
+	Healthy     bool              `json:"healthy"`
+	Regressions []string          `json:"regressions,omitempty"`
+	Assertions  []AssertionResult `json:"assertions,omitempty"`
+	Error       string            `json:"error,omitempty"`
+	HistoryID   int64             `json:"history_id,omitempty"`
+	Summary     string            `json:"summary,omitempty"` // LLM incident summary sent with the alert
+	AlertErrors []string          `json:"alert_errors,omitempty"`
+}
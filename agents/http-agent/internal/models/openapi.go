@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// OpenAPISpec represents a loaded OpenAPI (3.x) or Swagger (2.0) document
+type OpenAPISpec struct {
+	ID         string             `json:"id"`
+	Title      string             `json:"title"`
+	Version    string             `json:"version"`
+	Source     string             `json:"source,omitempty"` // URL the spec was fetched from
+	BaseURL    string             `json:"base_url"`
+	Operations []OpenAPIOperation `json:"operations"`
+	LoadedAt   time.Time          `json:"loaded_at"`
+}
+
+// OpenAPIOperation describes a single documented API operation
+type OpenAPIOperation struct {
+	ID              string                 `json:"id"` // operationId, or "METHOD /path" when missing
+	Method          string                 `json:"method"`
+	Path            string                 `json:"path"`
+	Summary         string                 `json:"summary,omitempty"`
+	Tags            []string               `json:"tags,omitempty"`
+	Example         *RequestConfig         `json:"example"`
+	ResponseSchemas map[string]interface{} `json:"response_schemas,omitempty"` // Keyed by status code
+}
+
+// OpenAPIReference links a request to a documented operation for contract validation
+type OpenAPIReference struct {
+	SpecID          string                 `json:"spec_id"`
+	OperationID     string                 `json:"operation_id"`
+	ResponseSchemas map[string]interface{} `json:"response_schemas,omitempty"` // Filled in by the agent
+}
+
+// OpenAPILoadRequest represents a request to load a spec by URL or inline content
+type OpenAPILoadRequest struct {
+	URL  string `json:"url"`
+	Spec string `json:"spec"` // Raw JSON or YAML document
+}
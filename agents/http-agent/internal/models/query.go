@@ -0,0 +1,19 @@
+package models
+
+// QueryRequest lists JSONPath or jq-style expressions to run against a stored response body
+type QueryRequest struct {
+	Expressions []string `json:"expressions" binding:"required,min=1"` // e.g. $.data.items[0].id or .data.items[].id
+}
+
+// QueryResult is the outcome of one expression
+type QueryResult struct {
+	Expression string        `json:"expression"`
+	Values     []interface{} `json:"values"` // Every matched value; empty when nothing matched
+	Error      string        `json:"error,omitempty"`
+}
+
+// QueryResponse holds the results of a query, in the order of the expressions
+type QueryResponse struct {
+	HistoryID int64         `json:"history_id"`
+	Results   []QueryResult `json:"results"`
+}
@@ -10,6 +10,68 @@ type RequestConfig struct {
 	Body      string            `json:"body"`
 	Prompt    string            `json:"prompt"`
 	VerifySSL *bool             `json:"verify_ssl"` // Optional, nil means use default
+	OpenAPI   *OpenAPIReference `json:"openapi,omitempty"`
+	// Investigate lets the LLM issue follow-up requests before answering
+	Investigate bool              `json:"investigate,omitempty"`
+	WebSocket   *WebSocketOptions `json:"websocket,omitempty"` // Used for ws:// and wss:// URLs
+	GraphQL     *GraphQLRequest   `json:"graphql,omitempty"`
+	Environment string            `json:"environment,omitempty"` // Named environment for {{variable}} substitution
+	Assertions  []Assertion       `json:"assertions,omitempty"`  // Checks reported as pass/fail next to the analysis
+	Profile     string            `json:"profile,omitempty"`     // Analysis profile selecting the prompt templates
+	// SecurityAudit adds the security header audit to the AI analysis
+	SecurityAudit bool `json:"security_audit,omitempty"`
+	// CookieJar names a jar that stores response cookies and sends them with later requests
+	CookieJar string `json:"cookie_jar,omitempty"`
+	// Compare sends the analysis to these configured LLM providers as well ("all" selects every one)
+	Compare []string     `json:"compare,omitempty"`
+	Auth    *RequestAuth `json:"auth,omitempty"` // Credentials turned into headers; secrets are redacted in results
+	// Reachability probes the target over TCP and ICMP before the request, for the AI analysis
+	Reachability *ReachabilityOptions `json:"reachability,omitempty"`
+	// TLSReport grades the TLS versions and cipher suites an HTTPS server accepts, for the AI analysis
+	TLSReport bool `json:"tls_report,omitempty"`
+}
+
+// ReachabilityOptions asks for network probes of the target host and port
+type ReachabilityOptions struct {
+	Traceroute bool                `json:"traceroute,omitempty"` // Also trace the route, up to a second per hop
+	MaxHops    int                 `json:"max_hops,omitempty"`   // Traceroute hop limit (default 30, at most 64)
+	Report     *ReachabilityReport `json:"-"`                    // Filled in by the agent for the analysis prompt
+}
+
+// RequestAuth describes the credentials the agent sends with a request
+type RequestAuth struct {
+	Type string `json:"type"` // basic, bearer, api_key or oauth2
+	// basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// bearer
+	Token string `json:"token,omitempty"`
+	// api_key: sent as a header (default X-API-Key) or a query parameter
+	Name  string `json:"name,omitempty"`
+	Value string `json:"value,omitempty"`
+	In    string `json:"in,omitempty"` // header or query
+	// oauth2 client credentials flow; tokens are cached until they expire
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	Audience     string   `json:"audience,omitempty"`
+}
+
+// GraphQLRequest describes a GraphQL operation; the agent shapes it into a POST body
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operation_name,omitempty"`
+	Introspect    bool                   `json:"introspect,omitempty"`
+	Schema        string                 `json:"schema,omitempty"` // Introspected schema summary, filled in by the agent
+}
+
+// WebSocketOptions controls a WebSocket exchange
+type WebSocketOptions struct {
+	Messages    []string `json:"messages"`     // Text frames sent after connecting
+	MaxMessages int      `json:"max_messages"` // Stop after receiving this many frames
+	Duration    int      `json:"duration"`     // Maximum capture time in seconds
 }
 
 // Response represents an HTTP response with metadata
@@ -22,6 +84,22 @@ type Response struct {
 	ContentType   string              `json:"content_type"`
 	ContentLength int64               `json:"content_length"`
 	Timestamp     time.Time           `json:"timestamp"`
+	// Body sizes of HTTP responses; the body is decoded when Content-Encoding is gzip, deflate or br
+	ContentEncoding  string              `json:"content_encoding,omitempty"`
+	CompressedSize   int64               `json:"compressed_size,omitempty"`   // Bytes received on the wire
+	DecompressedSize int64               `json:"decompressed_size,omitempty"` // Bytes after decoding; 0 if decoding failed
+	CompressionRatio float64             `json:"compression_ratio,omitempty"` // Decompressed size divided by compressed size
+	Frames           []WebSocketFrame    `json:"frames,omitempty"`            // WebSocket exchanges only
+	Trailers         map[string][]string `json:"trailers,omitempty"`          // gRPC calls only
+	TLS              *TLSReport          `json:"-"`                           // HTTPS targets; filled in by the agent for the analysis prompt
+}
+
+// WebSocketFrame is a message exchanged over a WebSocket connection
+type WebSocketFrame struct {
+	Direction string `json:"direction"` // sent or received
+	Type      string `json:"type"`      // text, binary, close or error
+	Data      string `json:"data"`      // Binary payloads are base64-encoded
+	Offset    string `json:"offset"`    // Time since the connection was opened
 }
 
 // DNSDiagnostics contains DNS resolution information
@@ -30,44 +108,226 @@ type DNSDiagnostics struct {
 	IPAddresses []string `json:"ip_addresses"`
 	Error       string   `json:"error,omitempty"`
 	LookupTime  string   `json:"lookup_time"`
+	// A, AAAA, CNAME, MX, TXT and NS records from the first configured resolver (or the system's)
+	Records   []DNSRecord         `json:"records,omitempty"`
+	Resolvers []DNSResolverResult `json:"resolvers,omitempty"` // Answers of each configured resolver
+	// Record sets that differ between resolvers, e.g. during propagation
+	Inconsistencies []string `json:"inconsistencies,omitempty"`
+}
+
+// DNSRecord is a resource record returned for the diagnosed host
+type DNSRecord struct {
+	Type  string  `json:"type"`
+	Value string  `json:"value"`
+	TTL   *uint32 `json:"ttl,omitempty"` // Seconds; unknown when the system resolver answered
+}
+
+// DNSResolverResult holds the records one resolver returned
+type DNSResolverResult struct {
+	Resolver   string      `json:"resolver"` // Address or DNS-over-HTTPS URL
+	Records    []DNSRecord `json:"records"`
+	Error      string      `json:"error,omitempty"`
+	LookupTime string      `json:"lookup_time"`
+}
+
+// ReachabilityReport tells network problems apart from application errors
+type ReachabilityReport struct {
+	Host            string          `json:"host"`
+	Address         string          `json:"address,omitempty"` // Probed IP address
+	Port            int             `json:"port"`
+	TCP             *ProbeResult    `json:"tcp,omitempty"`  // Connect latency to the target port
+	ICMP            *ProbeResult    `json:"icmp,omitempty"` // Echo requests (ping)
+	Traceroute      []TracerouteHop `json:"traceroute,omitempty"`
+	TracerouteError string          `json:"traceroute_error,omitempty"`
+	Error           string          `json:"error,omitempty"`
+}
+
+// ProbeResult summarizes a series of TCP connects or ICMP echoes
+type ProbeResult struct {
+	Sent        int     `json:"sent"`
+	Received    int     `json:"received"`
+	LossPercent float64 `json:"loss_percent"`
+	MinRTT      string  `json:"min_rtt,omitempty"`
+	AvgRTT      string  `json:"avg_rtt,omitempty"`
+	MaxRTT      string  `json:"max_rtt,omitempty"`
+	Error       string  `json:"error,omitempty"` // Last failure, or why the probe could not run
+}
+
+// TracerouteHop is a router on the path to the target
+type TracerouteHop struct {
+	Hop     int    `json:"hop"`
+	Address string `json:"address,omitempty"` // Empty when the hop did not answer
+	RTT     string `json:"rtt,omitempty"`
+	Target  bool   `json:"target,omitempty"` // The target itself answered
 }
 
 // SSLCertificateDiagnostics contains SSL/TLS certificate information
 type SSLCertificateDiagnostics struct {
-	Present         bool      `json:"present"`
-	Valid           bool      `json:"valid"`
-	Subject         string    `json:"subject"`
-	Issuer          string    `json:"issuer"`
-	NotBefore       time.Time `json:"not_before"`
-	NotAfter        time.Time `json:"not_after"`
-	ExpiresIn       string    `json:"expires_in"`
-	DNSNames        []string  `json:"dns_names"`
-	SignatureAlgo   string    `json:"signature_algorithm"`
-	PublicKeyAlgo   string    `json:"public_key_algorithm"`
-	Version         int       `json:"version"`
-	SerialNumber    string    `json:"serial_number"`
-	Error           string    `json:"error,omitempty"`
-	CertificateInfo string    `json:"certificate_info,omitempty"`
+	Present         bool       `json:"present"`
+	Valid           bool       `json:"valid"`
+	Subject         string     `json:"subject"`
+	Issuer          string     `json:"issuer"`
+	NotBefore       time.Time  `json:"not_before"`
+	NotAfter        time.Time  `json:"not_after"`
+	ExpiresIn       string     `json:"expires_in"`
+	DNSNames        []string   `json:"dns_names"`
+	SignatureAlgo   string     `json:"signature_algorithm"`
+	PublicKeyAlgo   string     `json:"public_key_algorithm"`
+	Version         int        `json:"version"`
+	SerialNumber    string     `json:"serial_number"`
+	Error           string     `json:"error,omitempty"`
+	CertificateInfo string     `json:"certificate_info,omitempty"`
+	TLS             *TLSReport `json:"tls,omitempty"` // Protocol versions and cipher suites the server accepts
+}
+
+// TLSReport grades the TLS configuration of an HTTPS server
+type TLSReport struct {
+	Version     string              `json:"version,omitempty"`      // Negotiated with the agent's default settings
+	CipherSuite string              `json:"cipher_suite,omitempty"` // Negotiated with the agent's default settings
+	Versions    []TLSVersionSupport `json:"versions"`               // TLS 1.0 to 1.3
+	// Cipher suites without forward secrecy or with broken algorithms that the server accepted
+	WeakCipherSuites []string          `json:"weak_cipher_suites,omitempty"`
+	Score            int               `json:"score"` // 0-100, reduced by every failed check according to its severity
+	Grade            string            `json:"grade"` // A to F
+	Findings         []SecurityFinding `json:"findings"`
+	Error            string            `json:"error,omitempty"`
+}
+
+// TLSVersionSupport tells whether the server accepts a protocol version
+type TLSVersionSupport struct {
+	Version     string `json:"version"`
+	Supported   bool   `json:"supported"`
+	CipherSuite string `json:"cipher_suite,omitempty"` // Preferred by the server when every suite is offered
 }
 
 // AnalysisResult contains the AI-generated analysis of the request/response
 type AnalysisResult struct {
-	Request          *RequestConfig             `json:"request"`
-	Response         *Response                  `json:"response"`
-	Analysis         string                     `json:"analysis"`
-	FormattedBody    string                     `json:"formatted_body,omitempty"`
-	Error            string                     `json:"error,omitempty"`
-	RequestDuration  string                     `json:"request_duration"`
-	DNSDiagnostics   *DNSDiagnostics            `json:"dns_diagnostics,omitempty"`
-	SSLDiagnostics   *SSLCertificateDiagnostics `json:"ssl_diagnostics,omitempty"`
-	SSLVerified      bool                       `json:"ssl_verified"`
+	Request         *RequestConfig             `json:"request"`
+	Response        *Response                  `json:"response"`
+	Analysis        string                     `json:"analysis"`
+	FormattedBody   string                     `json:"formatted_body,omitempty"`
+	Error           string                     `json:"error,omitempty"`
+	RequestDuration string                     `json:"request_duration"`
+	DNSDiagnostics  *DNSDiagnostics            `json:"dns_diagnostics,omitempty"`
+	SSLDiagnostics  *SSLCertificateDiagnostics `json:"ssl_diagnostics,omitempty"`
+	Reachability    *ReachabilityReport        `json:"reachability,omitempty"`
+	SSLVerified     bool                       `json:"ssl_verified"`
+	SessionID       string                     `json:"session_id,omitempty"`
+	HistoryID       int64                      `json:"history_id,omitempty"`
+	Investigation   []InvestigationStep        `json:"investigation,omitempty"`
+	Assertions      []AssertionResult          `json:"assertions,omitempty"`
+	Passed          *bool                      `json:"passed,omitempty"` // All assertions passed; nil without assertions
+	SecurityReport  *SecurityReport            `json:"security_report,omitempty"`
+	Comparison      []ProviderAnswer           `json:"comparison,omitempty"` // Answers of every compared provider, the primary first
+}
+
+// ProviderAnswer is the analysis of one LLM provider in comparison mode
+type ProviderAnswer struct {
+	Provider     string   `json:"provider"` // Configured provider name
+	Model        string   `json:"model,omitempty"`
+	Analysis     string   `json:"analysis,omitempty"`
+	Error        string   `json:"error,omitempty"`
+	Latency      string   `json:"latency"`
+	LatencyMs    int64    `json:"latency_ms"`
+	InputTokens  int      `json:"input_tokens,omitempty"`
+	OutputTokens int      `json:"output_tokens,omitempty"`
+	Cost         *float64 `json:"cost_usd,omitempty"` // nil when the provider has no configured prices
+}
+
+// ProviderModels lists the models a configured LLM provider offers
+type ProviderModels struct {
+	Name     string   `json:"name"`
+	Provider string   `json:"provider"` // Provider type, e.g. openai or ollama
+	Model    string   `json:"model"`    // Configured model
+	Models   []string `json:"models"`   // Models the provider reports; only the configured one for hosted APIs
+	Error    string   `json:"error,omitempty"`
+}
+
+// InvestigationStep records a follow-up request issued by the LLM during an investigation
+type InvestigationStep struct {
+	Reason     string         `json:"reason"`
+	Request    *RequestConfig `json:"request"`
+	StatusCode int            `json:"status_code,omitempty"`
+	Status     string         `json:"status,omitempty"`
+	Duration   string         `json:"duration,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// ChatMessage represents a single message exchanged with the LLM
+type ChatMessage struct {
+	Role    string `json:"role"` // user or assistant
+	Content string `json:"content"`
+}
+
+// Session holds a request/response pair and the follow-up conversation about it
+type Session struct {
+	ID        string         `json:"id"`
+	Request   *RequestConfig `json:"request"`
+	Response  *Response      `json:"response"`
+	Messages  []ChatMessage  `json:"messages"`
+	Provider  string         `json:"provider,omitempty"` // LLM provider answering follow-ups; empty for the main provider
+	Model     string         `json:"model,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// FollowUpRequest represents a follow-up question within a session
+type FollowUpRequest struct {
+	Question string `json:"question" binding:"required"`
+}
+
+// ModelSelection switches the LLM provider and model answering a session's follow-ups
+type ModelSelection struct {
+	Provider string `json:"provider" binding:"required"` // Configured provider name, see GET /api/v1/models
+	Model    string `json:"model,omitempty"`             // Defaults to the provider's configured model
+}
+
+// BuildRequestRequest asks the LLM to turn a natural-language description into a request
+type BuildRequestRequest struct {
+	Description string `json:"description" binding:"required"`
+}
+
+// HistoryEntry represents a persisted request execution
+type HistoryEntry struct {
+	ID         int64           `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	URL        string          `json:"url"`
+	Method     string          `json:"method"`
+	StatusCode int             `json:"status_code"`
+	Duration   time.Duration   `json:"duration"`
+	Error      string          `json:"error,omitempty"`
+	Result     *AnalysisResult `json:"result,omitempty"` // Only populated for single-entry lookups
+}
+
+// HistoryFilter narrows down history listings
+type HistoryFilter struct {
+	URL        string `form:"url"`    // Substring match
+	StatusCode int    `form:"status"` // Exact match
+	Limit      int    `form:"limit"`
+	Offset     int    `form:"offset"`
 }
 
 // Config represents the application configuration
 type Config struct {
-	Server ServerConfig `mapstructure:"server"`
-	LLM    LLMConfig    `mapstructure:"llm"`
-	HTTP   HTTPConfig   `mapstructure:"http"`
+	Server   ServerConfig   `mapstructure:"server"`
+	LLM      LLMConfig      `mapstructure:"llm"`
+	HTTP     HTTPConfig     `mapstructure:"http"`
+	Session  SessionConfig  `mapstructure:"session"`
+	History  HistoryConfig  `mapstructure:"history"`
+	Agent    AgentConfig    `mapstructure:"agent"`
+	Monitor  MonitorConfig  `mapstructure:"monitor"`
+	LoadTest LoadTestConfig `mapstructure:"load_test"`
+	Prompts  PromptConfig   `mapstructure:"prompts"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+	Logging  LoggingConfig  `mapstructure:"logging"`
+	Audit    AuditConfig    `mapstructure:"audit"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Limits   LimitsConfig   `mapstructure:"limits"`
+	DNS      DNSConfig      `mapstructure:"dns"`
+	// Comparison providers are only called for requests that ask to compare answers
+	Comparison ComparisonConfig `mapstructure:"comparison"`
+	// Environments predefined in the config file; more can be added through the API
+	Environments []Environment `mapstructure:"environments"`
 }
 
 // ServerConfig holds server-specific settings
@@ -76,22 +336,150 @@ type ServerConfig struct {
 	Host         string `mapstructure:"host"`
 	ReadTimeout  int    `mapstructure:"read_timeout"`
 	WriteTimeout int    `mapstructure:"write_timeout"`
+	// TrustedProxies lists the proxy addresses or CIDRs whose X-Forwarded-For header is trusted (none by default)
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // LLMConfig holds LLM provider configuration
 type LLMConfig struct {
+	Name     string `mapstructure:"name"`     // Label in comparisons; defaults to "default" for the primary provider
 	Provider string `mapstructure:"provider"` // openai, anthropic, ollama
 	APIKey   string `mapstructure:"api_key"`
 	Model    string `mapstructure:"model"`
 	BaseURL  string `mapstructure:"base_url"` // For Ollama
+	// Ollama only: how long the model stays loaded (e.g. "10m", "-1" forever) and its context window
+	KeepAlive string `mapstructure:"keep_alive"`
+	NumCtx    int    `mapstructure:"num_ctx"`
+	// Prices in USD per million tokens, used to report the cost of compared answers
+	InputCostPerMillion  float64 `mapstructure:"input_cost_per_million"`
+	OutputCostPerMillion float64 `mapstructure:"output_cost_per_million"`
+}
+
+// ComparisonConfig lists the additional LLM providers requests can compare answers with
+type ComparisonConfig struct {
+	Providers []LLMConfig `mapstructure:"providers"`
 }
 
 // HTTPConfig holds HTTP client configuration
 type HTTPConfig struct {
-	Timeout          int  `mapstructure:"timeout"`
-	FollowRedirects  bool `mapstructure:"follow_redirects"`
-	MaxRedirects     int  `mapstructure:"max_redirects"`
-	VerifySSL        bool `mapstructure:"verify_ssl"`
-	MaxResponseSize  int  `mapstructure:"max_response_size"`
-	BlockPrivateIPs  bool `mapstructure:"block_private_ips"`
+	Timeout         int  `mapstructure:"timeout"`
+	FollowRedirects bool `mapstructure:"follow_redirects"`
+	MaxRedirects    int  `mapstructure:"max_redirects"`
+	VerifySSL       bool `mapstructure:"verify_ssl"`
+	MaxResponseSize int  `mapstructure:"max_response_size"`
+	BlockPrivateIPs bool `mapstructure:"block_private_ips"`
+	// CABundle is a PEM file or a directory of PEM files with additional trusted root CAs
+	CABundle string       `mapstructure:"ca_bundle"`
+	Policy   TargetPolicy `mapstructure:"policy"`
+}
+
+// TargetPolicy restricts the hosts and ports requests may reach, including redirect hops
+type TargetPolicy struct {
+	AllowedHosts []string `mapstructure:"allowed_hosts"` // Host names, *.domain wildcards or IPs; empty allows any host
+	DeniedHosts  []string `mapstructure:"denied_hosts"`  // Host names, *.domain wildcards, IPs or CIDR ranges
+	AllowedPorts []int    `mapstructure:"allowed_ports"` // Empty allows any port
+	DeniedPorts  []int    `mapstructure:"denied_ports"`
+}
+
+// DNSConfig selects the resolvers queried by the DNS diagnostics
+type DNSConfig struct {
+	// Resolver addresses (8.8.8.8, 1.1.1.1:53) or DNS-over-HTTPS URLs; empty uses the system resolver.
+	// Results of several resolvers are compared
+	Resolvers []string `mapstructure:"resolvers"`
+}
+
+// SessionConfig holds conversation session settings
+type SessionConfig struct {
+	TTL         int `mapstructure:"ttl"`          // Idle lifetime in minutes
+	MaxSessions int `mapstructure:"max_sessions"` // Oldest sessions are evicted beyond this
+}
+
+// HistoryConfig holds request history persistence settings
+type HistoryConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"` // SQLite database file
+}
+
+// LoadTestConfig holds the guardrails of load testing, which is off by default
+type LoadTestConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`
+	AllowedHosts   []string `mapstructure:"allowed_hosts"` // Hosts that may be load tested; *.example.com matches subdomains
+	MaxConcurrency int      `mapstructure:"max_concurrency"`
+	MaxRequests    int      `mapstructure:"max_requests"`
+	MaxDuration    int      `mapstructure:"max_duration"` // Seconds
+}
+
+// MonitorConfig holds scheduled monitoring and alerting settings
+type MonitorConfig struct {
+	MaxRuns int        `mapstructure:"max_runs"` // Runs kept in memory per monitor
+	SMTP    SMTPConfig `mapstructure:"smtp"`
+}
+
+// SMTPConfig holds the mail server used for email alerts
+type SMTPConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+}
+
+// PromptConfig holds the location of custom prompt templates
+type PromptConfig struct {
+	Dir string `mapstructure:"dir"` // *.tmpl files overriding or adding to the built-in templates
+}
+
+// TracingConfig holds OpenTelemetry trace export settings
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"` // OTLP/HTTP collector, e.g. http://localhost:4318; empty uses OTEL_EXPORTER_OTLP_* variables
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"` // Fraction of new traces recorded; sampled parent traces are always recorded
+}
+
+// AuditConfig holds the outbound request audit log settings
+type AuditConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Path    string `mapstructure:"path"` // Append-only JSON Lines file
+}
+
+// AuthConfig holds API authentication settings
+type AuthConfig struct {
+	Enabled bool       `mapstructure:"enabled"`
+	APIKeys []APIKey   `mapstructure:"api_keys"`
+	OIDC    OIDCConfig `mapstructure:"oidc"`
+}
+
+// APIKey is a static key granting a role
+type APIKey struct {
+	Name string `mapstructure:"name"` // Recorded in the logs and the audit log
+	Key  string `mapstructure:"key"`
+	Role string `mapstructure:"role"` // viewer, operator or admin
+}
+
+// OIDCConfig holds the identity provider whose tokens are accepted
+type OIDCConfig struct {
+	Issuer    string `mapstructure:"issuer"`     // Discovery URL, e.g. https://login.example.com/realms/internal
+	Audience  string `mapstructure:"audience"`   // Expected aud claim (client ID); empty skips the check
+	RoleClaim string `mapstructure:"role_claim"` // Claim listing the roles; defaults to roles
+}
+
+// LimitsConfig holds the per-client rate limit and the global concurrency caps
+type LimitsConfig struct {
+	RequestsPerMinute     int `mapstructure:"requests_per_minute"`      // API calls per client; 0 disables the rate limit
+	Burst                 int `mapstructure:"burst"`                    // Calls a client may make at once before being limited
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests"`  // Outbound requests across all callers; 0 is unlimited
+	MaxConcurrentLLMCalls int `mapstructure:"max_concurrent_llm_calls"` // LLM calls across all callers; 0 is unlimited
+	QueueTimeout          int `mapstructure:"queue_timeout"`            // Seconds to wait for a free slot before refusing
+}
+
+// LoggingConfig holds structured logging settings
+type LoggingConfig struct {
+	Level  string `mapstructure:"level"`  // debug, info, warn or error
+	Format string `mapstructure:"format"` // json or text
+}
+
+// AgentConfig holds settings for autonomous investigations
+type AgentConfig struct {
+	MaxSteps int `mapstructure:"max_steps"` // Follow-up requests allowed per investigation
 }
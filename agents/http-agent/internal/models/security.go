@@ -0,0 +1,17 @@
+package models
+
+// SecurityReport is the outcome of the deterministic security header audit of a response
+type SecurityReport struct {
+	Score    int               `json:"score"` // 0-100, reduced by every failed check according to its severity
+	Grade    string            `json:"grade"` // A to F
+	Findings []SecurityFinding `json:"findings"`
+}
+
+// SecurityFinding is the result of a single security header or cookie check
+type SecurityFinding struct {
+	Check    string `json:"check"` // Header name, HTTPS, or Set-Cookie: <name>
+	Passed   bool   `json:"passed"`
+	Severity string `json:"severity,omitempty"` // high, medium or low for failed checks
+	Message  string `json:"message"`
+	Value    string `json:"value,omitempty"` // Header value the check was based on
+}
@@ -0,0 +1,43 @@
+package models
+
+// Workflow is a sequence of requests where values extracted from one response
+// are injected into the following requests through {{variable}} placeholders
+type Workflow struct {
+	Name        string            `json:"name"`
+	Environment string            `json:"environment"` // Named environment providing base variables
+	Variables   map[string]string `json:"variables"`   // Initial values, override the environment
+	CookieJar   string            `json:"cookie_jar"`  // Cookie jar for steps that do not select one
+	Steps       []WorkflowStep    `json:"steps" binding:"required,min=1"`
+	Prompt      string            `json:"prompt"` // Question for the summary of the whole flow
+}
+
+// WorkflowStep is a single request of a workflow
+type WorkflowStep struct {
+	Name    string        `json:"name"`
+	Request RequestConfig `json:"request"`
+	// Extract maps variable names to sources: a JSONPath into the body ($.data.token),
+	// a response header (header:X-Request-Id) or the status code (status)
+	Extract map[string]string `json:"extract"`
+	// ExpectStatus fails the step on a different status code; by default 4xx/5xx fail it
+	ExpectStatus int `json:"expect_status"`
+}
+
+// WorkflowStepResult is the outcome of a workflow step
+type WorkflowStepResult struct {
+	Name       string            `json:"name"`
+	Request    *RequestConfig    `json:"request"` // With placeholders substituted
+	Response   *Response         `json:"response,omitempty"`
+	Duration   string            `json:"duration,omitempty"`
+	Extracted  map[string]string `json:"extracted,omitempty"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// WorkflowResult is the outcome of a workflow run
+type WorkflowResult struct {
+	Name       string               `json:"name"`
+	Steps      []WorkflowStepResult `json:"steps"`
+	Variables  map[string]string    `json:"variables"`
+	FailedStep string               `json:"failed_step,omitempty"` // Name of the step where the flow broke
+	Summary    string               `json:"summary"`
+}
@@ -0,0 +1,133 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the correlation ID of an inbound call and of the requests it causes
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds the request IDs accepted from callers
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// NewLogger creates a structured logger writing JSON (default) or text lines at the configured level.
+// Records logged with a context carry its request ID and trace ID
+func NewLogger(w io.Writer, config *models.LoggingConfig) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if strings.EqualFold(config.Format, "text") {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// contextHandler adds the request ID and trace ID of the record's context
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the correlation attributes before delegating
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs keeps the wrapper around derived handlers
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the wrapper around derived handlers
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// NewRequestID returns a random 128-bit hex ID
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ValidRequestID reports whether a caller-supplied request ID can be reused: at most 128
+// printable ASCII characters without spaces, so it cannot forge log lines or headers
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a context carrying a request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID of a context, or "" when there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Transport sends the context's request ID with outbound requests that do not set one
+// and logs every round trip
+type Transport struct {
+	Base      http.RoundTripper
+	Component string // Logged with each request, e.g. "http" for target requests or "llm"
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if id := RequestID(ctx); id != "" && req.Header.Get(RequestIDHeader) == "" {
+		req = req.Clone(ctx)
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+
+	// The query string is left out as it may carry credentials
+	attrs := []any{
+		slog.String("component", t.Component),
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "outbound request failed", append(attrs, slog.String("error", err.Error()))...)
+		return nil, err
+	}
+	slog.InfoContext(ctx, "outbound request", append(attrs, slog.Int("status", resp.StatusCode))...)
+	return resp, nil
+}
@@ -0,0 +1,64 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/adeotek/adeotek-ai-tools/agents/http-agent/internal/models"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// SetupTracing installs the global trace context propagator and, when tracing is enabled, a tracer
+// provider exporting spans over OTLP/HTTP. The returned function flushes and stops the exporter
+func SetupTracing(ctx context.Context, config *models.TracingConfig) (func(context.Context) error, error) {
+	// Propagate incoming trace context even when this service does not export spans
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !config.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	var options []otlptracehttp.Option
+	if config.Endpoint != "" {
+		endpoint, err := url.Parse(config.Endpoint)
+		if err != nil || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid tracing endpoint %q", config.Endpoint)
+		}
+		options = append(options, otlptracehttp.WithEndpointURL(endpoint.JoinPath("/v1/traces").String()))
+	}
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "http-agent"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}